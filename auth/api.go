@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+func (t *Authenticator) ConfigureRouter(r *gin.Engine) {
+	api := r.Group("/api")
+	{
+		api.POST("/v1/login", func(c *gin.Context) {
+			var params LoginParams
+			err := c.BindJSON(&params)
+			if err != nil {
+				utils.JsonError(c, err.Error(), http.StatusBadRequest)
+				return
+			}
+			address := c.ClientIP()
+			if wait := t.limiter.Allow(address); wait > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				utils.JsonError(c, "too many failed logins, try again later", http.StatusTooManyRequests)
+				return
+			}
+			token, claims, err := t.Login(params.Password)
+			if err == errInvalidPassword {
+				t.limiter.Fail(address)
+				t.logger.Warnf("Failed login from %s", address)
+				utils.JsonError(c, err.Error(), http.StatusUnauthorized)
+				return
+			} else if err != nil {
+				utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			t.limiter.Succeed(address)
+			maxAge := int(claims.ExpiresAt - claims.IssuedAt)
+			c.SetSameSite(http.SameSiteStrictMode)
+			c.SetCookie(TokenCookie, token, maxAge, "/", "", c.Request.TLS != nil, true)
+			c.JSON(http.StatusOK, LoginResult{Token: token, ExpiresAt: claims.ExpiresAt})
+		})
+
+		api.POST("/v1/logout", func(c *gin.Context) {
+			if err := t.Logout(GetClaims(c)); err != nil {
+				utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			c.SetSameSite(http.SameSiteStrictMode)
+			c.SetCookie(TokenCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+			c.Status(http.StatusNoContent)
+		})
+
+		// logs out everyone, e.g. after a session token leaked
+		api.DELETE("/v1/sessions", Require(ScopeAdmin), func(c *gin.Context) {
+			if err := t.RevokeSessions(); err != nil {
+				utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			c.SetSameSite(http.SameSiteStrictMode)
+			c.SetCookie(TokenCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+			c.Status(http.StatusNoContent)
+		})
+
+		api.GET("/v1/tokens", Require(ScopeAdmin), func(c *gin.Context) {
+			tokens, err := t.tokens.List()
+			if err != nil {
+				utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			c.JSON(http.StatusOK, tokens)
+		})
+
+		api.POST("/v1/tokens", Require(ScopeAdmin), func(c *gin.Context) {
+			var params TokenParams
+			err := c.BindJSON(&params)
+			if err != nil {
+				utils.JsonError(c, err.Error(), http.StatusBadRequest)
+				return
+			}
+			scopes, err := ParseScopes(params.Scopes)
+			if err != nil {
+				utils.JsonError(c, err.Error(), http.StatusBadRequest)
+				return
+			}
+			secret, token, err := t.tokens.Create(params.Name, scopes)
+			if err != nil {
+				utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			c.JSON(http.StatusCreated, CreateTokenResult{Token: *token, Secret: secret})
+		})
+
+		api.GET("/v1/tokens/:id", Require(ScopeAdmin), func(c *gin.Context) {
+			token, err := t.tokens.Get(c.Param("id"))
+			if err == errTokenNotFound {
+				utils.JsonError(c, err.Error(), http.StatusNotFound)
+				return
+			} else if err != nil {
+				utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			c.JSON(http.StatusOK, token)
+		})
+
+		api.PUT("/v1/tokens/:id", Require(ScopeAdmin), func(c *gin.Context) {
+			var params TokenParams
+			err := c.BindJSON(&params)
+			if err != nil {
+				utils.JsonError(c, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var scopes []Scope
+			if len(params.Scopes) > 0 {
+				scopes, err = ParseScopes(params.Scopes)
+				if err != nil {
+					utils.JsonError(c, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			token, err := t.tokens.Update(c.Param("id"), params.Name, scopes)
+			if err == errTokenNotFound {
+				utils.JsonError(c, err.Error(), http.StatusNotFound)
+				return
+			} else if err != nil {
+				utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			c.JSON(http.StatusOK, token)
+		})
+
+		api.DELETE("/v1/tokens/:id", Require(ScopeAdmin), func(c *gin.Context) {
+			err := t.tokens.Delete(c.Param("id"))
+			if err == errTokenNotFound {
+				utils.JsonError(c, err.Error(), http.StatusNotFound)
+				return
+			} else if err != nil {
+				utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			c.Status(http.StatusNoContent)
+		})
+	}
+}
+
+type LoginParams struct {
+	Password string `json:"password"`
+}
+
+type LoginResult struct {
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+type TokenParams struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type CreateTokenResult struct {
+	Token
+	Secret string `json:"secret"`
+}
@@ -0,0 +1,362 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	TokenCookie = "proxy_token"
+	ClaimsKey   = "auth.claims"
+
+	DefaultSessionTimeout = 24 * time.Hour
+)
+
+var (
+	errNoToken         = errors.New("authentication required")
+	errInvalidToken    = errors.New("invalid token")
+	errExpiredToken    = errors.New("token expired")
+	errInvalidPassword = errors.New("invalid password")
+	errRevokedToken    = errors.New("token revoked")
+	errForbidden       = errors.New("permission denied")
+
+	publicPaths = map[string]bool{
+		"/api/v1/login": true,
+	}
+)
+
+type Claims struct {
+	Id        string  `json:"jti,omitempty"`
+	Subject   string  `json:"sub"`
+	Scopes    []Scope `json:"scopes"`
+	IssuedAt  int64   `json:"iat"`
+	ExpiresAt int64   `json:"exp"`
+	Epoch     int64   `json:"epoch,omitempty"`
+}
+
+type Authenticator struct {
+	dataDir      string
+	key          []byte
+	adminSecret  string
+	passwordHash []byte
+	tokens       *TokenStore
+	sessions     *SessionList
+	limiter      *LoginLimiter
+	mutex        *sync.RWMutex
+	logger       *logrus.Entry
+}
+
+// NewAuthenticator loads the signing key and admin secret from dataDir,
+// generating them on first start.
+func NewAuthenticator(dataDir string) (*Authenticator, error) {
+	logger := logrus.NewEntry(logrus.StandardLogger()).WithField("name", "auth")
+
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return nil, err
+	}
+
+	t := &Authenticator{
+		dataDir: dataDir,
+		limiter: NewLoginLimiter(),
+		mutex:   &sync.RWMutex{},
+		logger:  logger,
+	}
+
+	key, _, err := t.loadOrGenerate("session.key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session key: %s", err)
+	}
+	t.key = []byte(key)
+
+	secret, generated, err := t.loadOrGenerate("admin.secret")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load admin secret: %s", err)
+	}
+	t.adminSecret = secret
+	if generated {
+		logger.Infof("Generated admin secret at %s", filepath.Join(dataDir, "admin.secret"))
+	}
+
+	tokens, err := NewTokenStore(filepath.Join(dataDir, "tokens.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokens: %s", err)
+	}
+	t.tokens = tokens
+
+	sessions, err := NewSessionList(filepath.Join(dataDir, "sessions.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sessions: %s", err)
+	}
+	t.sessions = sessions
+
+	hash, err := ioutil.ReadFile(filepath.Join(dataDir, "password"))
+	if err == nil {
+		t.passwordHash = hash
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load password: %s", err)
+	}
+
+	return t, nil
+}
+
+func (t *Authenticator) GetTokenStore() *TokenStore {
+	return t.tokens
+}
+
+func (t *Authenticator) loadOrGenerate(name string) (string, bool, error) {
+	file := filepath.Join(t.dataDir, name)
+	content, err := ioutil.ReadFile(file)
+	if err == nil {
+		return strings.TrimSpace(string(content)), false, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", false, err
+	}
+	value, err := randomHex(32)
+	if err != nil {
+		return "", false, err
+	}
+	if err := ioutil.WriteFile(file, []byte(value), 0600); err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// SetPassword remembers the xud wallet password so that it can be used to
+// log in as well as the admin secret. Only a bcrypt hash is stored.
+func (t *Authenticator) SetPassword(password string) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		t.logger.Errorf("Failed to hash password: %s", err)
+		return
+	}
+	err = ioutil.WriteFile(filepath.Join(t.dataDir, "password"), hash, 0600)
+	if err != nil {
+		t.logger.Errorf("Failed to save password: %s", err)
+		return
+	}
+	t.mutex.Lock()
+	t.passwordHash = hash
+	t.mutex.Unlock()
+}
+
+func (t *Authenticator) checkPassword(password string) bool {
+	if subtle.ConstantTimeCompare([]byte(password), []byte(t.adminSecret)) == 1 {
+		return true
+	}
+	t.mutex.RLock()
+	hash := t.passwordHash
+	t.mutex.RUnlock()
+	if hash == nil {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+}
+
+// Logout revokes the session token of claims. API tokens are revoked by
+// deleting them.
+func (t *Authenticator) Logout(claims *Claims) error {
+	if claims == nil || claims.Id == "" {
+		return nil
+	}
+	return t.sessions.Revoke(claims)
+}
+
+// RevokeSessions invalidates all session tokens.
+func (t *Authenticator) RevokeSessions() error {
+	return t.sessions.RevokeAll()
+}
+
+// Login checks the password and issues a session token for it.
+func (t *Authenticator) Login(password string) (string, *Claims, error) {
+	if !t.checkPassword(password) {
+		return "", nil, errInvalidPassword
+	}
+	id, err := randomHex(16)
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
+	claims := &Claims{
+		Id:        id,
+		Subject:   "admin",
+		Scopes:    []Scope{ScopeAdmin},
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(DefaultSessionTimeout).Unix(),
+		Epoch:     t.sessions.GetEpoch(),
+	}
+	token, err := t.sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+func (t *Authenticator) signature(payload string) string {
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (t *Authenticator) sign(claims *Claims) (string, error) {
+	j, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(j)
+	return payload + "." + t.signature(payload), nil
+}
+
+// VerifyToken checks the signature, expiry and revocation of a session
+// token.
+func (t *Authenticator) VerifyToken(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, errInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[1]), []byte(t.signature(parts[0]))) {
+		return nil, errInvalidToken
+	}
+	j, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(j, &claims); err != nil {
+		return nil, errInvalidToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, errExpiredToken
+	}
+	if !t.sessions.Valid(&claims) {
+		return nil, errRevokedToken
+	}
+	return &claims, nil
+}
+
+// acceptsQueryToken reports whether r comes from a WebSocket, EventSource or
+// Socket.IO client, which cannot set headers. Other requests must not pass the
+// token in the URL, where it would end up in logs and the browser history.
+func acceptsQueryToken(r *http.Request) bool {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return true
+	}
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return true
+	}
+	return r.URL != nil && strings.HasPrefix(r.URL.Path, "/socket.io")
+}
+
+// ExtractToken looks for a token in the Authorization header, the session
+// cookie and, for streaming clients, the "token" query parameter (in that
+// order).
+func ExtractToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(h, "Bearer "))
+	}
+	if cookie, err := r.Cookie(TokenCookie); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	if r.URL != nil && acceptsQueryToken(r) {
+		return r.URL.Query().Get("token")
+	}
+	return ""
+}
+
+func (t *Authenticator) AuthenticateRequest(r *http.Request) (*Claims, error) {
+	token := ExtractToken(r)
+	if token == "" {
+		return nil, errNoToken
+	}
+	if strings.HasPrefix(token, tokenPrefix) {
+		apiToken, err := t.tokens.Lookup(token)
+		if err != nil {
+			return nil, err
+		}
+		return &Claims{
+			Subject:  "token:" + apiToken.Id,
+			Scopes:   apiToken.Scopes,
+			IssuedAt: apiToken.CreatedAt,
+		}, nil
+	}
+	return t.VerifyToken(token)
+}
+
+func isProtected(path string) bool {
+	if publicPaths[path] {
+		return false
+	}
+	return strings.HasPrefix(path, "/api/") ||
+		strings.HasPrefix(path, "/socket.io") ||
+		strings.HasPrefix(path, "/launcher") ||
+		path == "/metrics"
+}
+
+// Middleware rejects unauthenticated requests to the API, Socket.IO,
+// launcher and metrics endpoints. Static UI files are left open.
+func (t *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodOptions || !isProtected(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		claims, err := t.AuthenticateRequest(c.Request)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+		c.Set(ClaimsKey, claims)
+		c.Next()
+	}
+}
+
+func GetClaims(c *gin.Context) *Claims {
+	value, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil
+	}
+	return value.(*Claims)
+}
+
+// Require rejects requests whose token does not carry the given scope. It is
+// meant to be attached to individual routes after the Middleware has
+// authenticated the request.
+func Require(scope Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims == nil || !HasScope(claims.Scopes, scope) {
+			utils.JsonError(c, fmt.Sprintf("%s: %s scope required", errForbidden, scope), http.StatusForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// failed logins allowed before an address has to wait
+	loginFreeAttempts = 5
+	loginBaseDelay    = time.Second
+	loginMaxDelay     = 15 * time.Minute
+	// failures are forgotten after this long without another attempt
+	loginFailureTtl = time.Hour
+)
+
+type loginFailures struct {
+	count        int
+	last         time.Time
+	blockedUntil time.Time
+}
+
+// LoginLimiter slows down password guessing. After loginFreeAttempts failed
+// logins from an address, every further failure doubles the time it has to
+// wait before the next attempt, up to loginMaxDelay.
+type LoginLimiter struct {
+	failures map[string]*loginFailures
+	mutex    *sync.Mutex
+}
+
+func NewLoginLimiter() *LoginLimiter {
+	return &LoginLimiter{
+		failures: map[string]*loginFailures{},
+		mutex:    &sync.Mutex{},
+	}
+}
+
+// Allow returns 0 if address may try to log in, otherwise how long it has to
+// wait.
+func (t *LoginLimiter) Allow(address string) time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	f, ok := t.failures[address]
+	if !ok {
+		return 0
+	}
+	now := time.Now()
+	if now.Sub(f.last) > loginFailureTtl {
+		delete(t.failures, address)
+		return 0
+	}
+	if now.Before(f.blockedUntil) {
+		return f.blockedUntil.Sub(now)
+	}
+	return 0
+}
+
+func (t *LoginLimiter) Fail(address string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	for key, f := range t.failures {
+		if now.Sub(f.last) > loginFailureTtl {
+			delete(t.failures, key)
+		}
+	}
+
+	f, ok := t.failures[address]
+	if !ok {
+		f = &loginFailures{}
+		t.failures[address] = f
+	}
+	f.count++
+	f.last = now
+	if f.count >= loginFreeAttempts {
+		delay := loginMaxDelay
+		if n := f.count - loginFreeAttempts; n < 20 {
+			if d := loginBaseDelay << uint(n); d < loginMaxDelay {
+				delay = d
+			}
+		}
+		f.blockedUntil = now.Add(delay)
+	}
+}
+
+func (t *LoginLimiter) Succeed(address string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.failures, address)
+}
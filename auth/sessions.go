@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// SessionList is the server side state of session tokens: the ids of tokens
+// revoked by logging out, kept until they would have expired anyway, and an
+// epoch which invalidates all sessions issued before it was increased.
+type SessionList struct {
+	file    string
+	Epoch   int64            `json:"epoch"`
+	Revoked map[string]int64 `json:"revoked"`
+	mutex   *sync.RWMutex
+}
+
+func NewSessionList(file string) (*SessionList, error) {
+	t := &SessionList{
+		file:    file,
+		Revoked: map[string]int64{},
+		mutex:   &sync.RWMutex{},
+	}
+	content, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, t); err != nil {
+		return nil, err
+	}
+	if t.Revoked == nil {
+		t.Revoked = map[string]int64{}
+	}
+	return t, nil
+}
+
+func (t *SessionList) save() error {
+	j, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(t.file, j, 0600)
+}
+
+func (t *SessionList) GetEpoch() int64 {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.Epoch
+}
+
+// Valid reports whether the session is neither revoked nor from an earlier
+// epoch.
+func (t *SessionList) Valid(claims *Claims) bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	if claims.Epoch != t.Epoch {
+		return false
+	}
+	_, revoked := t.Revoked[claims.Id]
+	return !revoked
+}
+
+func (t *SessionList) Revoke(claims *Claims) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now().Unix()
+	for id, expiresAt := range t.Revoked {
+		if expiresAt <= now {
+			delete(t.Revoked, id)
+		}
+	}
+	if claims.Id != "" {
+		t.Revoked[claims.Id] = claims.ExpiresAt
+	}
+	return t.save()
+}
+
+// RevokeAll invalidates every session token issued so far.
+func (t *SessionList) RevokeAll() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.Epoch++
+	t.Revoked = map[string]int64{}
+	return t.save()
+}
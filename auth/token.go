@@ -0,0 +1,278 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Scope string
+
+const (
+	ScopeRead    Scope = "read"
+	ScopeTrade   Scope = "trade"
+	ScopeWallet  Scope = "wallet"
+	ScopeConsole Scope = "console"
+	ScopeAdmin   Scope = "admin"
+
+	tokenPrefix = "xdt_"
+)
+
+var (
+	AllScopes = []Scope{ScopeRead, ScopeTrade, ScopeWallet, ScopeConsole, ScopeAdmin}
+
+	errTokenNotFound = errors.New("token not found")
+)
+
+func ParseScopes(values []string) ([]Scope, error) {
+	var result []Scope
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			scope := Scope(item)
+			valid := false
+			for _, s := range AllScopes {
+				if s == scope {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return nil, fmt.Errorf("invalid scope: %s", item)
+			}
+			result = append(result, scope)
+		}
+	}
+	if len(result) == 0 {
+		return nil, errors.New("no scopes")
+	}
+	return result, nil
+}
+
+// HasScope reports whether scopes grant the given scope. The admin scope
+// grants everything.
+func HasScope(scopes []Scope, scope Scope) bool {
+	for _, s := range scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+type Token struct {
+	Id        string  `json:"id"`
+	Name      string  `json:"name"`
+	Scopes    []Scope `json:"scopes"`
+	CreatedAt int64   `json:"createdAt"`
+	Hash      string  `json:"hash,omitempty"`
+}
+
+// TokenStore keeps API tokens in a JSON file. Only the SHA-256 hash of each
+// token is stored. The file is reloaded when it is changed by another
+// process (e.g. "proxy token create").
+type TokenStore struct {
+	file    string
+	tokens  map[string]*Token
+	modTime time.Time
+	mutex   *sync.Mutex
+}
+
+func NewTokenStore(file string) (*TokenStore, error) {
+	s := &TokenStore{
+		file:   file,
+		tokens: map[string]*Token{},
+		mutex:  &sync.Mutex{},
+	}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *TokenStore) reload() error {
+	info, err := os.Stat(t.file)
+	if os.IsNotExist(err) {
+		t.tokens = map[string]*Token{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(t.modTime) {
+		return nil
+	}
+	content, err := ioutil.ReadFile(t.file)
+	if err != nil {
+		return err
+	}
+	var tokens []*Token
+	if err := json.Unmarshal(content, &tokens); err != nil {
+		return fmt.Errorf("failed to parse %s: %s", t.file, err)
+	}
+	t.tokens = map[string]*Token{}
+	for _, token := range tokens {
+		t.tokens[token.Id] = token
+	}
+	t.modTime = info.ModTime()
+	return nil
+}
+
+func (t *TokenStore) save() error {
+	tokens := make([]*Token, 0, len(t.tokens))
+	for _, token := range t.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt < tokens[j].CreatedAt
+	})
+	content, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(t.file, content, 0600); err != nil {
+		return err
+	}
+	if info, err := os.Stat(t.file); err == nil {
+		t.modTime = info.ModTime()
+	}
+	return nil
+}
+
+func publicToken(token *Token) Token {
+	result := *token
+	result.Hash = ""
+	return result
+}
+
+func (t *TokenStore) List() ([]Token, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+	result := make([]Token, 0, len(t.tokens))
+	for _, token := range t.tokens {
+		result = append(result, publicToken(token))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt < result[j].CreatedAt
+	})
+	return result, nil
+}
+
+func (t *TokenStore) Get(id string) (*Token, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+	token, ok := t.tokens[id]
+	if !ok {
+		return nil, errTokenNotFound
+	}
+	result := publicToken(token)
+	return &result, nil
+}
+
+// Create mints a new token. The returned secret is not stored and cannot be
+// recovered later.
+func (t *TokenStore) Create(name string, scopes []Scope) (string, *Token, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if err := t.reload(); err != nil {
+		return "", nil, err
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", nil, err
+	}
+	secret = tokenPrefix + secret
+	token := &Token{
+		Id:        id,
+		Name:      name,
+		Scopes:    scopes,
+		CreatedAt: time.Now().Unix(),
+		Hash:      hashToken(secret),
+	}
+	t.tokens[id] = token
+	if err := t.save(); err != nil {
+		delete(t.tokens, id)
+		return "", nil, err
+	}
+	result := publicToken(token)
+	return secret, &result, nil
+}
+
+func (t *TokenStore) Update(id string, name string, scopes []Scope) (*Token, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+	token, ok := t.tokens[id]
+	if !ok {
+		return nil, errTokenNotFound
+	}
+	if name != "" {
+		token.Name = name
+	}
+	if len(scopes) > 0 {
+		token.Scopes = scopes
+	}
+	if err := t.save(); err != nil {
+		return nil, err
+	}
+	result := publicToken(token)
+	return &result, nil
+}
+
+func (t *TokenStore) Delete(id string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if err := t.reload(); err != nil {
+		return err
+	}
+	if _, ok := t.tokens[id]; !ok {
+		return errTokenNotFound
+	}
+	delete(t.tokens, id)
+	return t.save()
+}
+
+// Lookup finds the token matching secret.
+func (t *TokenStore) Lookup(secret string) (*Token, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+	hash := hashToken(secret)
+	for _, token := range t.tokens {
+		if token.Hash == hash {
+			result := publicToken(token)
+			return &result, nil
+		}
+	}
+	return nil, errInvalidToken
+}
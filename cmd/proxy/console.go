@@ -3,6 +3,8 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
 	"github.com/creack/pty"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -10,6 +12,7 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"strings"
 	"sync"
 )
 
@@ -25,9 +28,9 @@ type Console struct {
 	Pty          *os.File `json:"-"`
 }
 
-func init() {
-	router.GET("/api/v1/consoles", listConsoles)
-	router.GET("/api/v1/consoles/:id", getConsole)
+func initConsoleRouter() {
+	router.GET("/api/v1/consoles", auth.Require(auth.ScopeConsole), listConsoles)
+	router.GET("/api/v1/consoles/:id", auth.Require(auth.ScopeConsole), getConsole)
 }
 
 func findById(id string) *Console {
@@ -90,7 +93,7 @@ func removeConsoles(connectionId string) {
 	}
 }
 
-var help = `\
+var generalHelp = `\
 Xucli shortcut commands
   addcurrency <currency>                    add a currency
   <swap_client> [decimal_places]
@@ -150,22 +153,43 @@ General commands
   help                                      show this help
   exit                                      exit xud-ctl shell
 
-CLI commands
-  bitcoin-cli                               bitcoind cli
-  litecoin-cli                              litecoind cli
-  lndbtc-lncli                              lnd cli
-  lndltc-lncli                              lnd cli
-  geth                                      geth cli
-  xucli                                     xud cli
-  boltzcli                                  boltz cli
+`
 
-Boltzcli shortcut commands  
+var boltzHelp = `Boltzcli shortcut commands  
   boltzcli <chain> deposit 
   --inbound [inbound_balance]               deposit from boltz (btc/ltc)
   boltzcli <chain> withdraw 
   <amount> <address>                        withdraw from boltz channel
 `
 
+// cliHelp lists the CLIs registered by the service packages.
+func cliHelp() string {
+	var b strings.Builder
+	b.WriteString("CLI commands\n")
+	for _, f := range core.GetFactories() {
+		for _, cmd := range f.Commands {
+			b.WriteString(fmt.Sprintf("  %-42s%s\n", cmd.Name, cmd.Description))
+		}
+	}
+	return b.String()
+}
+
+func getHelp() string {
+	return generalHelp + cliHelp() + "\n" + boltzHelp
+}
+
+// cliFunctions defines a bash function for every registered CLI which runs
+// the command inside the service container.
+func cliFunctions() string {
+	var b strings.Builder
+	for _, f := range core.GetFactories() {
+		for _, cmd := range f.Commands {
+			b.WriteString(fmt.Sprintf("function %s() {\n\tdocker exec -it ${NETWORK}_%s_1 %s $@\n}\n", cmd.Name, f.Name, cmd.Command))
+		}
+	}
+	return b.String()
+}
+
 func writeInitScript(network string) {
 	f, err := os.Create("init.bash")
 	if err != nil {
@@ -190,7 +214,7 @@ EOF
 export NETWORK=` + network + `
 export PS1="$NETWORK > "
 function help() {
-	echo "` + help + `"
+	echo "` + getHelp() + `"
 }
 function start() {
 	docker start ${NETWORK}_${1}_1 
@@ -211,41 +235,13 @@ function report() {
 	cat <<EOF
 Please click on https://github.com/ExchangeUnion/xud/issues/\
 new?assignees=kilrau&labels=bug&template=bug-report.md&title=Short%2C+concise+\
-description+of+the+bug, describe your issue, drag and drop the file "${NETWORK}\
-.log" which is located in "{logs_dir}" into your browser window and submit \
-your issue.
+description+of+the+bug, describe your issue, download the diagnostic bundle \
+from /api/v1/report of this proxy, drag and drop it into your browser window \
+and submit your issue. Passwords, macaroons, mnemonics and xpubs are removed \
+from the bundle.
 EOF
 }
-function xucli() {
-	docker exec -it ${NETWORK}_xud_1 xucli $@
-}
-function lndbtc-lncli() {
-	docker exec -it ${NETWORK}_lndbtc_1 lncli -n ${NETWORK} -c bitcoin $@
-}
-function lndltc-lncli() {
-	docker exec -it ${NETWORK}_lndltc_1 lncli -n ${NETWORK} -c litecoin $@
-}
-function geth() {
-	docker exec -it ${NETWORK}_geth_1 geth $@
-}
-function bitcoin-ctl() {	
-	if [[ $NETWORK == "testnet" ]]; then
-		docker exec -it ${NETWORK}_bitcoind_1 -testnet -user xu -password xu bitcoind $@
-	else
-		docker exec -it ${NETWORK}_bitcoind_1 -user xu -password xu bitcoind $@
-	fi
-}
-function litecoin-ctl() {
-	if [[ $NETWORK == "testnet" ]]; then
-		docker exec -it ${NETWORK}_litecoind_1 -testnet -user xu -password xu litecoind $@
-	else
-		docker exec -it ${NETWORK}_litecoind_1 -user xu -password xu litecoind $@
-	fi
-}
-function boltzcli() {
-	docker exec -it ${NETWORK}_boltz_1 wrapper $@
-}
-
+` + cliFunctions() + `
 alias getinfo='xucli getinfo'
 alias addcurrency='xucli addcurrency'
 alias addpair='xucli addpair'
@@ -294,8 +290,19 @@ func startShell(console *Console, size TerminalSize) error {
 	return nil
 }
 
+// allowConsole checks that the Socket.IO connection was authenticated with a
+// token carrying the console scope (see NewSioServer).
+func allowConsole(s socketio.Conn) bool {
+	claims, ok := s.Context().(*auth.Claims)
+	return ok && auth.HasScope(claims.Scopes, auth.ScopeConsole)
+}
+
 func initSioConsole() {
 	sioServer.OnEvent("/", "create", func(s socketio.Conn, data string) {
+		if !allowConsole(s) {
+			s.Emit("created", "permission denied: console scope required")
+			return
+		}
 		network := os.Getenv("NETWORK")
 		id := fmt.Sprint(uuid.New())
 		console := Console{
@@ -310,6 +317,10 @@ func initSioConsole() {
 	})
 
 	sioServer.OnEvent("/", "start", func(s socketio.Conn, data string) {
+		if !allowConsole(s) {
+			s.Emit("start", "permission denied: console scope required")
+			return
+		}
 		req := StartRequest{}
 		err := json.Unmarshal([]byte(data), &req)
 		if err != nil {
@@ -334,6 +345,9 @@ func initSioConsole() {
 		outputEvent := fmt.Sprintf("console.%s.output", console.Id)
 
 		sioServer.OnEvent("/", inputEvent, func(s socketio.Conn, data string) {
+			if !allowConsole(s) {
+				return
+			}
 			logger.Debugf("[console/%s] ---> %v", console.Id, data)
 
 			pty_ := console.Pty
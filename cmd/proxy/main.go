@@ -2,9 +2,13 @@ package main
 
 import (
 	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/config"
 	"github.com/ExchangeUnion/xud-docker-api/launcher"
 	"github.com/ExchangeUnion/xud-docker-api/logging"
 	"github.com/ExchangeUnion/xud-docker-api/service"
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"github.com/ExchangeUnion/xud-docker-api/service/xud"
 	"github.com/docker/docker/pkg/homedir"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -22,9 +26,13 @@ var (
 	router    = initRouter()
 	sioServer *socketio.Server
 
-	port uint16
-	tls bool
-	network string
+	authenticator *auth.Authenticator
+
+	port    uint16
+	tls     bool
+	network    string
+	dataDir    string
+	configFile string
 )
 
 func initLogger() *logrus.Entry {
@@ -53,14 +61,7 @@ func initRouter() *gin.Engine {
 	r.Use(logging.LoggerOverLogrus())
 	r.Use(gin.Recovery())
 
-	r.NoRoute(func(c *gin.Context) {
-		if strings.HasPrefix(c.Request.URL.Path, "/api") {
-			c.JSON(404, gin.H{"message": "not found"})
-		} else {
-			// redirect other non-API requests to ui/index.html to fit SPA requirements
-			c.File("ui/index.html")
-		}
-	})
+	r.NoRoute(notFound)
 	r.NoMethod(func(c *gin.Context) {
 		c.JSON(405, gin.H{"message": "method not allowed"})
 	})
@@ -70,6 +71,15 @@ func initRouter() *gin.Engine {
 	return r
 }
 
+func notFound(c *gin.Context) {
+	if strings.HasPrefix(c.Request.URL.Path, "/api") {
+		c.JSON(404, gin.H{"message": "not found"})
+	} else {
+		// redirect other non-API requests to ui/index.html to fit SPA requirements
+		c.File("ui/index.html")
+	}
+}
+
 func setupCors(r gin.IRouter) {
 	// Configuring CORS
 	// - No origin allowed by default
@@ -82,14 +92,26 @@ func setupCors(r gin.IRouter) {
 	r.Use(cors.New(config))
 }
 
+func initAuth() {
+	a, err := auth.NewAuthenticator(dataDir)
+	if err != nil {
+		logger.Fatalf("Failed to create authenticator: %s", err)
+	}
+	authenticator = a
+
+	router.Use(authenticator.Middleware())
+	authenticator.ConfigureRouter(router)
+}
+
 func initSioServer() {
-	server, err := NewSioServer(network)
+	server, err := NewSioServer(network, authenticator)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
 	sioServer = server
 	initSioConsole()
+	initConsoleRouter()
 
 	go func() {
 		err := server.Serve()
@@ -109,28 +131,37 @@ func initSioServer() {
 }
 
 func initLauncherWs() {
-	router.GET("/launcher", gin.WrapF(launcher.WsHandler))
-	router.Handle("WS", "/launcher", gin.WrapF(launcher.WsHandler))
+	router.GET("/launcher", auth.Require(auth.ScopeAdmin), gin.WrapF(launcher.WsHandler))
+	router.Handle("WS", "/launcher", auth.Require(auth.ScopeAdmin), gin.WrapF(launcher.WsHandler))
 
 	go launcher.StartLauncherRegistry()
 
 	launcher.ConfigureRouter(router)
 }
 
-func initServiceManager() {
-	logger.Debug("Creating service manager")
-	manager, err := service.NewManager(network)
+func initServiceManager() *service.Manager {
+	logger.Debugf("Creating service manager (config=%s)", configFile)
+	manager, err := service.NewManager(network, configFile, dataDir)
 	if err != nil {
 		logger.Fatalf("Failed to create service manager: %s", err)
 	}
-	defer func() {
-		err := manager.Close()
-		if err != nil {
-			logger.Fatalf("Failed to close service manager: %s", err)
-		}
-	}()
 
 	manager.ConfigureRouter(router)
+	// the routes of the services are swapped on reload, see ServeServiceRoutes
+	router.NoRoute(manager.ServeServiceRoutes, notFound)
+
+	go pushStatus(manager)
+	go pushSwaps(manager)
+
+	// xud is created again when its config changes, and it may be a "docker"
+	// entry without an API
+	manager.OnServiceCreated(func(s core.Service) {
+		if x, ok := s.(*xud.Service); ok {
+			x.AddPasswordListener(authenticator.SetPassword)
+		}
+	})
+
+	return manager
 }
 
 func serve() error {
@@ -140,8 +171,8 @@ func serve() error {
 	logger.Infof("Serving at %s", addr)
 
 	if tls {
-		certFile := filepath.Join(homedir.Get(), ".proxy", "tls.crt")
-		keyFile := filepath.Join(homedir.Get(), ".proxy", "tls.key")
+		certFile := filepath.Join(dataDir, "tls.crt")
+		keyFile := filepath.Join(dataDir, "tls.key")
 		err = http.ListenAndServeTLS(addr, certFile, keyFile, router)
 	} else {
 		err = http.ListenAndServe(addr, router)
@@ -152,28 +183,41 @@ func serve() error {
 	return nil
 }
 
+func run(cmd *cobra.Command, args []string) {
+	initAuth()
+	initSioServer()
+	initLauncherWs()
+	manager := initServiceManager()
+	defer func() {
+		err := manager.Close()
+		if err != nil {
+			logger.Errorf("Failed to close service manager: %s", err)
+		}
+	}()
+
+	err := serve()
+	if err != nil {
+		logger.Errorf("Failed to serve: %s", err)
+	}
+}
+
 func main() {
 	var err error
 
 	network = os.Getenv("NETWORK")
 
 	cmd := &cobra.Command{
-		Use: "proxy",
+		Use:   "proxy",
 		Short: "The API gateway of xud-docker",
+		Run:   run,
 	}
 	cmd.PersistentFlags().Uint16VarP(&port, "port", "p", 8080, "The port to listen")
 	cmd.PersistentFlags().BoolVar(&tls, "tls", false, "Enable TLS support")
+	cmd.PersistentFlags().StringVar(&dataDir, "data-dir", filepath.Join(homedir.Get(), ".proxy"), "The directory of proxy data files")
+	cmd.PersistentFlags().StringVar(&configFile, "config", config.GetConfigFile(), fmt.Sprintf("The xud-docker config file (env %s)", config.ConfigFileEnv))
+	cmd.AddCommand(newTokenCommand())
 	err = cmd.Execute()
 	if err != nil {
 		logger.Fatalf("Failed to parse command-line options: %s", err)
 	}
-
-	initSioServer()
-	initLauncherWs()
-	initServiceManager()
-
-	err = serve()
-	if err != nil {
-		logger.Fatalf("Failed to serve: %s", err)
-	}
 }
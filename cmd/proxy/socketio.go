@@ -1,6 +1,9 @@
 package main
 
 import (
+	"encoding/json"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/service"
 	socketio "github.com/googollee/go-socket.io"
 	"github.com/googollee/go-socket.io/engineio"
 	"github.com/googollee/go-socket.io/engineio/transport"
@@ -9,12 +12,11 @@ import (
 	"net/http"
 )
 
-func NewSioServer(network string) (*socketio.Server, error) {
+func NewSioServer(network string, authenticator *auth.Authenticator) (*socketio.Server, error) {
 	pt := polling2.Default
+	// the default origin check is kept, the token cookie would otherwise
+	// let any website use the console
 	wt := websocket.Default
-	wt.CheckOrigin = func(r *http.Request) bool {
-		return true
-	}
 	server, err := socketio.NewServer(&engineio.Options{
 		Transports: []transport.Transport{
 			pt,
@@ -26,6 +28,16 @@ func NewSioServer(network string) (*socketio.Server, error) {
 	}
 	server.OnConnect("/", func(s socketio.Conn) error {
 		logger.Debugf("[SocketIO/%s] CONNECT: RemoteAddr=%v", s.ID(), s.RemoteAddr())
+		u := s.URL()
+		claims, err := authenticator.AuthenticateRequest(&http.Request{Header: s.RemoteHeader(), URL: &u})
+		if err != nil {
+			logger.Debugf("[SocketIO/%s] Rejected: %s", s.ID(), err)
+			return err
+		}
+		s.SetContext(claims)
+		if auth.HasScope(claims.Scopes, auth.ScopeRead) {
+			s.Join("status")
+		}
 		t := s.RemoteHeader().Get("X-Type")
 		if t != "" {
 			logger.Debugf("[SocketIO/%s] Type=%s", s.ID(), t)
@@ -55,3 +67,31 @@ func NewSioServer(network string) (*socketio.Server, error) {
 
 	return server, nil
 }
+
+// pushStatus forwards status changes to the Socket.IO clients in the "status"
+// room as "status" events.
+func pushStatus(manager *service.Manager) {
+	updates, _ := manager.SubscribeStatus()
+	for status := range updates {
+		j, err := json.Marshal(status)
+		if err != nil {
+			logger.Errorf("Failed to marshal status: %s", err)
+			continue
+		}
+		sioServer.BroadcastToRoom("/", "status", "status", string(j))
+	}
+}
+
+// pushSwaps forwards swap events to the Socket.IO clients in the "status"
+// room as "swap" events.
+func pushSwaps(manager *service.Manager) {
+	events, _ := manager.SubscribeSwaps()
+	for event := range events {
+		j, err := json.Marshal(event)
+		if err != nil {
+			logger.Errorf("Failed to marshal swap event: %s", err)
+			continue
+		}
+		sioServer.BroadcastToRoom("/", "status", "swap", string(j))
+	}
+}
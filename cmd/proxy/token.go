@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+)
+
+func newTokenStore() *auth.TokenStore {
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		logger.Fatalf("Failed to create %s: %s", dataDir, err)
+	}
+	store, err := auth.NewTokenStore(filepath.Join(dataDir, "tokens.json"))
+	if err != nil {
+		logger.Fatalf("Failed to load tokens: %s", err)
+	}
+	return store
+}
+
+func newTokenCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Manage API tokens",
+	}
+
+	var name string
+	var scopes []string
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Mint a new API token",
+		Run: func(cmd *cobra.Command, args []string) {
+			s, err := auth.ParseScopes(scopes)
+			if err != nil {
+				logger.Fatalf("Failed to create token: %s", err)
+			}
+			secret, token, err := newTokenStore().Create(name, s)
+			if err != nil {
+				logger.Fatalf("Failed to create token: %s", err)
+			}
+			fmt.Printf("Created token %s (%s) with scopes %v\n", token.Id, token.Name, token.Scopes)
+			fmt.Println(secret)
+		},
+	}
+	createCmd.Flags().StringVar(&name, "name", "", "The name of the token")
+	createCmd.Flags().StringSliceVar(&scopes, "scopes", []string{string(auth.ScopeRead)}, "The scopes of the token (read, trade, wallet, console, admin)")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List API tokens",
+		Run: func(cmd *cobra.Command, args []string) {
+			tokens, err := newTokenStore().List()
+			if err != nil {
+				logger.Fatalf("Failed to list tokens: %s", err)
+			}
+			for _, token := range tokens {
+				fmt.Printf("%s\t%s\t%v\n", token.Id, token.Name, token.Scopes)
+			}
+		},
+	}
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete <id>",
+		Short: "Delete an API token",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := newTokenStore().Delete(args[0])
+			if err != nil {
+				logger.Fatalf("Failed to delete token: %s", err)
+			}
+		},
+	}
+
+	cmd.AddCommand(createCmd, listCmd, deleteCmd)
+
+	return cmd
+}
@@ -1,9 +1,271 @@
 package config
 
-import "time"
-
-type RpcConfig = map[string]interface{}
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
 
 const (
 	DefaultApiTimeout = 30 * time.Second
+
+	DefaultConfigFile = "/root/network/data/config.json"
+	ConfigFileEnv     = "PROXY_CONFIG"
+)
+
+type RpcKind string
+
+const (
+	None    RpcKind = ""
+	Grpc    RpcKind = "gRPC"
+	JsonRpc RpcKind = "JSON-RPC"
+	Http    RpcKind = "HTTP"
+	Boltz   RpcKind = "boltz"
 )
+
+// rpcKinds maps each supported service to the kind of its "rpc" block. It is
+// populated by RegisterService.
+var rpcKinds = map[string]RpcKind{}
+
+// DockerOnly is the type of config.json entries describing a plain container
+// without any RPC interface, e.g. a custom market-maker.
+const DockerOnly = "docker"
+
+// ProxyService is the name of this proxy. It is always added as a service
+// and can't be configured in config.json.
+const ProxyService = "proxy"
+
+// RegisterService declares a supported service so that its config.json entry
+// can be validated.
+func RegisterService(name string, kind RpcKind) {
+	rpcKinds[name] = kind
+}
+
+type GrpcConfig struct {
+	Host     string `json:"host"`
+	Port     uint16 `json:"port"`
+	TlsCert  string `json:"tlsCert"`
+	Macaroon string `json:"macaroon"`
+}
+
+type JsonRpcConfig struct {
+	Host     string `json:"host"`
+	Port     uint16 `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type HttpConfig struct {
+	Host string `json:"host"`
+	Port uint16 `json:"port"`
+}
+
+type BoltzConfig struct {
+	Bitcoin  GrpcConfig `json:"bitcoin"`
+	Litecoin GrpcConfig `json:"litecoin"`
+}
+
+type ServiceConfig struct {
+	Name     string          `json:"name"`
+	Disabled bool            `json:"disabled"`
+	Mode     string          `json:"mode"`
+	Rpc      json.RawMessage `json:"rpc"`
+
+	// The following fields are only used by "docker" entries
+	Type        string `json:"type"`
+	DisplayName string `json:"displayName"`
+	Container   string `json:"container"`
+}
+
+type Config struct {
+	Services []ServiceConfig `json:"services"`
+}
+
+// GetConfigFile returns the config file path from the environment, falling
+// back to the default location in xud-docker.
+func GetConfigFile() string {
+	if value := os.Getenv(ConfigFileEnv); value != "" {
+		return value
+	}
+	return DefaultConfigFile
+}
+
+// Load reads and validates the config file.
+func Load(file string) (*Config, error) {
+	f, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(f)
+}
+
+func Parse(data []byte) (*Config, error) {
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid config: %s", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (t *Config) Validate() error {
+	var errs []string
+	names := map[string]bool{}
+	for i, s := range t.Services {
+		prefix := fmt.Sprintf("services[%d]", i)
+		if s.Name == "" {
+			errs = append(errs, prefix+": name is required")
+			continue
+		}
+		prefix = fmt.Sprintf("%s (%s)", prefix, s.Name)
+		if names[s.Name] {
+			errs = append(errs, prefix+": duplicated service")
+			continue
+		}
+		names[s.Name] = true
+		if s.Name == ProxyService {
+			errs = append(errs, prefix+": reserved service name")
+			continue
+		}
+		if err := s.validate(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", prefix, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("invalid config: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (t *Config) GetService(name string) *ServiceConfig {
+	for i := range t.Services {
+		if t.Services[i].Name == name {
+			return &t.Services[i]
+		}
+	}
+	return nil
+}
+
+func (t *ServiceConfig) validate() error {
+	if t.Type == DockerOnly {
+		return nil
+	}
+	if t.Type != "" {
+		return fmt.Errorf("unsupported type: %s", t.Type)
+	}
+	kind, ok := rpcKinds[t.Name]
+	if !ok {
+		return errors.New("unsupported service (use \"type\": \"docker\" for custom containers)")
+	}
+	var err error
+	switch kind {
+	case Grpc:
+		_, err = t.GrpcConfig()
+	case JsonRpc:
+		_, err = t.JsonRpcConfig()
+	case Http:
+		_, err = t.HttpConfig()
+	case Boltz:
+		_, err = t.BoltzConfig()
+	}
+	return err
+}
+
+func (t *ServiceConfig) decodeRpc(v interface{}) error {
+	if len(t.Rpc) == 0 || string(t.Rpc) == "null" {
+		return errors.New("rpc is required")
+	}
+	if err := json.Unmarshal(t.Rpc, v); err != nil {
+		return fmt.Errorf("invalid rpc: %s", err)
+	}
+	return nil
+}
+
+func (t *ServiceConfig) defaultTlsCert() string {
+	return fmt.Sprintf("/root/network/data/%s/tls.cert", t.Name)
+}
+
+func validateGrpc(prefix string, c *GrpcConfig) error {
+	if c.Port == 0 {
+		return fmt.Errorf("%s.port is required", prefix)
+	}
+	if c.TlsCert == "" {
+		return fmt.Errorf("%s.tlsCert is required", prefix)
+	}
+	return nil
+}
+
+func (t *ServiceConfig) GrpcConfig() (*GrpcConfig, error) {
+	var c GrpcConfig
+	if err := t.decodeRpc(&c); err != nil {
+		return nil, err
+	}
+	if c.Host == "" {
+		c.Host = t.Name
+	}
+	if c.TlsCert == "" {
+		c.TlsCert = t.defaultTlsCert()
+	}
+	if err := validateGrpc("rpc", &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (t *ServiceConfig) JsonRpcConfig() (*JsonRpcConfig, error) {
+	var c JsonRpcConfig
+	if err := t.decodeRpc(&c); err != nil {
+		return nil, err
+	}
+	if c.Host == "" {
+		c.Host = t.Name
+	}
+	if c.Username == "" && c.Password == "" && t.Name != "geth" {
+		c.Username = "xu"
+		c.Password = "xu"
+	}
+	if c.Port == 0 {
+		return nil, errors.New("rpc.port is required")
+	}
+	return &c, nil
+}
+
+func (t *ServiceConfig) HttpConfig() (*HttpConfig, error) {
+	var c HttpConfig
+	if err := t.decodeRpc(&c); err != nil {
+		return nil, err
+	}
+	if c.Host == "" {
+		c.Host = t.Name
+	}
+	if c.Port == 0 {
+		return nil, errors.New("rpc.port is required")
+	}
+	return &c, nil
+}
+
+func (t *ServiceConfig) BoltzConfig() (*BoltzConfig, error) {
+	var c BoltzConfig
+	if err := t.decodeRpc(&c); err != nil {
+		return nil, err
+	}
+	if c.Bitcoin.Host == "" {
+		c.Bitcoin.Host = t.Name
+	}
+	if c.Litecoin.Host == "" {
+		c.Litecoin.Host = t.Name
+	}
+	if err := validateGrpc("rpc.bitcoin", &c.Bitcoin); err != nil {
+		return nil, err
+	}
+	if err := validateGrpc("rpc.litecoin", &c.Litecoin); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
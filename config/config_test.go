@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterService("xud", Grpc)
+	RegisterService("bitcoind", JsonRpc)
+	RegisterService("geth", JsonRpc)
+	RegisterService("connext", Http)
+	RegisterService("boltz", Boltz)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		// err is a substring of the expected error, empty for none
+		err string
+	}{
+		{
+			name: "valid",
+			json: `{"services": [
+				{"name": "xud", "rpc": {"port": 8886}},
+				{"name": "bitcoind", "rpc": {"port": 18443}},
+				{"name": "connext", "rpc": {"port": 5040}},
+				{"name": "boltz", "rpc": {"bitcoin": {"port": 9002, "tlsCert": "/btc.cert"}, "litecoin": {"port": 9102, "tlsCert": "/ltc.cert"}}},
+				{"name": "mm", "type": "docker", "container": "mainnet_mm_1"}
+			]}`,
+		},
+		{name: "malformed", json: `{"services": [`, err: "invalid config"},
+		{name: "missing name", json: `{"services": [{"rpc": {}}]}`, err: "services[0]: name is required"},
+		{
+			name: "duplicated",
+			json: `{"services": [{"name": "mm", "type": "docker"}, {"name": "mm", "type": "docker"}]}`,
+			err:  "services[1] (mm): duplicated service",
+		},
+		{name: "proxy", json: `{"services": [{"name": "proxy", "type": "docker"}]}`, err: "reserved service name"},
+		{name: "unsupported service", json: `{"services": [{"name": "foo"}]}`, err: "unsupported service"},
+		{name: "unsupported type", json: `{"services": [{"name": "xud", "type": "vm"}]}`, err: "unsupported type: vm"},
+		{name: "missing rpc", json: `{"services": [{"name": "xud"}]}`, err: "rpc is required"},
+		{name: "missing port", json: `{"services": [{"name": "xud", "rpc": {}}]}`, err: "rpc.port is required"},
+		{name: "invalid rpc", json: `{"services": [{"name": "xud", "rpc": {"port": "x"}}]}`, err: "invalid rpc"},
+		{
+			name: "missing boltz tlsCert",
+			json: `{"services": [{"name": "boltz", "rpc": {"bitcoin": {"port": 9002}, "litecoin": {"port": 9102}}}]}`,
+			err:  "rpc.bitcoin.tlsCert is required",
+		},
+		{
+			name: "missing boltz port",
+			json: `{"services": [{"name": "boltz", "rpc": {"bitcoin": {"port": 9002, "tlsCert": "/btc.cert"}, "litecoin": {}}}]}`,
+			err:  "rpc.litecoin.port is required",
+		},
+		{
+			name: "all errors",
+			json: `{"services": [{"name": "xud", "rpc": {}}, {"name": "connext", "rpc": {}}]}`,
+			err:  "services[0] (xud): rpc.port is required; services[1] (connext): rpc.port is required",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse([]byte(test.json))
+			if test.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error containing %q", test.err)
+			}
+			if !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("error %q doesn't contain %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestGrpcConfigDefaults(t *testing.T) {
+	tests := []struct {
+		rpc  string
+		want GrpcConfig
+	}{
+		{
+			rpc:  `{"port": 8886}`,
+			want: GrpcConfig{Host: "xud", Port: 8886, TlsCert: "/root/network/data/xud/tls.cert"},
+		},
+		{
+			rpc:  `{"host": "localhost", "port": 8886, "tlsCert": "/tls.cert", "macaroon": "/admin.macaroon"}`,
+			want: GrpcConfig{Host: "localhost", Port: 8886, TlsCert: "/tls.cert", Macaroon: "/admin.macaroon"},
+		},
+	}
+	for _, test := range tests {
+		c := ServiceConfig{Name: "xud", Rpc: json.RawMessage(test.rpc)}
+		got, err := c.GrpcConfig()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.rpc, err)
+		}
+		if *got != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.rpc, *got, test.want)
+		}
+	}
+}
+
+func TestJsonRpcConfigDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		rpc  string
+		want JsonRpcConfig
+	}{
+		{
+			name: "bitcoind",
+			rpc:  `{"port": 18443}`,
+			want: JsonRpcConfig{Host: "bitcoind", Port: 18443, Username: "xu", Password: "xu"},
+		},
+		{
+			name: "bitcoind",
+			rpc:  `{"port": 18443, "username": "alice", "password": "secret"}`,
+			want: JsonRpcConfig{Host: "bitcoind", Port: 18443, Username: "alice", Password: "secret"},
+		},
+		{
+			name: "geth",
+			rpc:  `{"port": 8545}`,
+			want: JsonRpcConfig{Host: "geth", Port: 8545},
+		},
+	}
+	for _, test := range tests {
+		c := ServiceConfig{Name: test.name, Rpc: json.RawMessage(test.rpc)}
+		got, err := c.JsonRpcConfig()
+		if err != nil {
+			t.Fatalf("%s %s: unexpected error: %s", test.name, test.rpc, err)
+		}
+		if *got != test.want {
+			t.Errorf("%s %s: got %+v, want %+v", test.name, test.rpc, *got, test.want)
+		}
+	}
+}
+
+func TestBoltzConfigDefaults(t *testing.T) {
+	c := ServiceConfig{Name: "boltz", Rpc: json.RawMessage(`{"bitcoin": {"port": 9002, "tlsCert": "/btc.cert"}, "litecoin": {"host": "ltc", "port": 9102, "tlsCert": "/ltc.cert"}}`)}
+	got, err := c.BoltzConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := BoltzConfig{
+		Bitcoin:  GrpcConfig{Host: "boltz", Port: 9002, TlsCert: "/btc.cert"},
+		Litecoin: GrpcConfig{Host: "ltc", Port: 9102, TlsCert: "/ltc.cert"},
+	}
+	if *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+}
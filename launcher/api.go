@@ -1,6 +1,7 @@
 package launcher
 
 import (
+	"github.com/ExchangeUnion/xud-docker-api/auth"
 	"github.com/ExchangeUnion/xud-docker-api/utils"
 	"github.com/gin-gonic/contrib/static"
 	"github.com/gin-gonic/gin"
@@ -12,7 +13,7 @@ func ConfigureRouter(r *gin.Engine) {
 
 	api := r.Group("/api")
 	{
-		api.GET("/v1/info", func(c *gin.Context) {
+		api.GET("/v1/info", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 			info, err := GetInfo()
 			if err != nil {
 				utils.JsonError(c, err.Error(), http.StatusInternalServerError)
@@ -20,7 +21,7 @@ func ConfigureRouter(r *gin.Engine) {
 			}
 			c.JSON(http.StatusOK, info)
 		})
-		api.PUT("/v1/backup", func(c *gin.Context) {
+		api.PUT("/v1/backup", auth.Require(auth.ScopeAdmin), func(c *gin.Context) {
 			var settings BackupSettings
 			err := c.BindJSON(&settings)
 			if err != nil {
@@ -36,9 +37,8 @@ func ConfigureRouter(r *gin.Engine) {
 				utils.JsonError(c, "", http.StatusBadRequest)
 			}
 		})
-		api.GET("/v1/launcher/setup-status", func(c *gin.Context) {
+		api.GET("/v1/launcher/setup-status", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 
 		})
 	}
 }
-
@@ -3,11 +3,32 @@ package logging
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"math"
+	"strconv"
 	"time"
 )
 
+// RouteKey can be set on the context to the route pattern of a request
+// which gin didn't match itself, e.g. because it was handed over to another
+// engine.
+const RouteKey = "logging.route"
+
+var (
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "proxy",
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "path", "status"})
+)
+
+func init() {
+	prometheus.MustRegister(requestDuration)
+}
+
 func LoggerOverLogrus() gin.HandlerFunc {
 
 	return func(c *gin.Context) {
@@ -22,6 +43,16 @@ func LoggerOverLogrus() gin.HandlerFunc {
 		clientIp := c.ClientIP()
 		method := c.Request.Method
 
+		// use the route pattern instead of the path to keep the cardinality low
+		route := c.FullPath()
+		if route == "" {
+			route = c.GetString(RouteKey)
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+		requestDuration.WithLabelValues(method, route, strconv.Itoa(statusCode)).Observe(stop.Seconds())
+
 		logger := logrus.NewEntry(logrus.StandardLogger()).WithFields(logrus.Fields{
 			"name": "gin",
 			"statusCode": statusCode,
@@ -32,4 +63,4 @@ func LoggerOverLogrus() gin.HandlerFunc {
 		})
 		logger.Debugf(fmt.Sprintf("[%s] %s %s | %d | %dms", clientIp, method, path, statusCode, latency))
 	}
-}
\ No newline at end of file
+}
@@ -3,6 +3,7 @@ package rpc
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
@@ -46,11 +47,20 @@ type GrpcConn struct {
 	mutex *sync.RWMutex
 	client interface{}
 
+	// generation is incremented by Update and Close. An Open loop only
+	// installs its connection if no newer loop was started in the meantime
+	generation uint64
+
 	newClientFunc func(*grpc.ClientConn) interface{}
 
+	// opened is closed and replaced each time a connection is established
+	opened chan struct{}
+
 	logger *logrus.Entry
 }
 
+var errStaleConn = errors.New("superseded by a newer connection")
+
 func NewGrpcConn(host string, port uint16, tlsCert string, macaroon string, logger *logrus.Entry, newClientFunc func(*grpc.ClientConn) interface{}) *GrpcConn {
 	conn := &GrpcConn{
 		host: host,
@@ -64,49 +74,71 @@ func NewGrpcConn(host string, port uint16, tlsCert string, macaroon string, logg
 
 		newClientFunc: newClientFunc,
 
+		opened: make(chan struct{}),
+
 		logger: logger,
 	}
 
 	return conn
 }
 
-func (t *GrpcConn) Update(host string, port uint16, tlsCert string, macaroon string) error {
+// Update switches to a new endpoint if it differs from the current one. The
+// current connection is closed and the new one is opened in the background,
+// any loop still connecting to the old endpoint gives up.
+func (t *GrpcConn) Update(host string, port uint16, tlsCert string, macaroon string) {
 	// TODO update when tlsCert or macaroon file changed
+	t.mutex.Lock()
+	if host == t.host && port == t.port && tlsCert == t.tlsCert && macaroon == t.macaroon {
+		t.mutex.Unlock()
+		return
+	}
 	t.host = host
 	t.port = port
 	t.tlsCert = tlsCert
 	t.macaroon = macaroon
+	t.generation++
+	generation := t.generation
+	old := t.conn
+	t.conn = nil
+	t.client = nil
+	t.mutex.Unlock()
 
-	if err := t.reopen(); err != nil {
-		return err
-	}
-
-	return nil
+	t.closeConn(old)
+	go t.open(generation)
 }
 
-func (t *GrpcConn) reopen() error {
-	if err := t.Close(); err != nil {
-		return err
-	}
-	t.Open()
-	return nil
+// Open connects to the endpoint, retrying until it succeeds or Update or
+// Close is called.
+func (t *GrpcConn) Open() {
+	t.mutex.RLock()
+	generation := t.generation
+	t.mutex.RUnlock()
+	t.open(generation)
 }
 
-func (t *GrpcConn) Open() {
+func (t *GrpcConn) open(generation uint64) {
 	for {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		err := t.connect(ctx)
+		err := t.connect(ctx, generation)
 		cancel()
-		if err == nil {
-			break
+		if err == nil || err == errStaleConn {
+			return
 		}
 		t.logger.Debugf("Failed to establish gRPC connection: %s", err)
 		time.Sleep(3 * time.Second)
 	}
 }
 
-func (t *GrpcConn) connect(ctx context.Context) error {
-	creds, err := credentials.NewClientTLSFromFile(t.tlsCert, "localhost")
+func (t *GrpcConn) connect(ctx context.Context, generation uint64) error {
+	t.mutex.RLock()
+	current := generation == t.generation
+	host, port, tlsCert, macaroon := t.host, t.port, t.tlsCert, t.macaroon
+	t.mutex.RUnlock()
+	if !current {
+		return errStaleConn
+	}
+
+	creds, err := credentials.NewClientTLSFromFile(tlsCert, "localhost")
 	if err != nil {
 		return err
 	}
@@ -115,41 +147,62 @@ func (t *GrpcConn) connect(ctx context.Context) error {
 	opts = append(opts, grpc.WithTransportCredentials(creds))
 	opts = append(opts, grpc.WithBlock())
 
-	if t.macaroon != "" {
-		if _, err := os.Stat(t.macaroon); os.IsNotExist(err) {
+	if macaroon != "" {
+		if _, err := os.Stat(macaroon); os.IsNotExist(err) {
 			return err
 		}
-		macaroonCred := MacaroonCredential(t.macaroon)
+		macaroonCred := MacaroonCredential(macaroon)
 		opts = append(opts, grpc.WithPerRPCCredentials(&macaroonCred))
 	}
 
-	addr := fmt.Sprintf("%s:%d", t.host, t.port)
+	addr := fmt.Sprintf("%s:%d", host, port)
 
-	t.logger.Debugf("Establishing gRPC connection to %s (tlsCert=%s, macaroon=%s)", addr, t.tlsCert, t.macaroon)
+	t.logger.Debugf("Establishing gRPC connection to %s (tlsCert=%s, macaroon=%s)", addr, tlsCert, macaroon)
 	conn, err := grpc.DialContext(ctx, addr, opts...)
 	if err != nil {
 		return err
 	}
+
+	t.mutex.Lock()
+	if generation != t.generation {
+		t.mutex.Unlock()
+		t.closeConn(conn)
+		return errStaleConn
+	}
+	old := t.conn
+	t.conn = conn
+	t.client = t.newClientFunc(conn)
+	close(t.opened)
+	t.opened = make(chan struct{})
+	t.mutex.Unlock()
+
+	t.closeConn(old)
 	t.logger.Debugf("Established gRPC connection")
 
 	go func() {
-		changed := conn.WaitForStateChange(ctx, connectivity.Ready)
+		changed := conn.WaitForStateChange(context.Background(), connectivity.Ready)
 		if changed {
 			t.logger.Debugf("gRPC connection broken: %s", conn.GetState())
 		}
 	}()
 
-	t.mutex.Lock()
-	t.conn = conn
-	t.client = t.newClientFunc(conn)
-	t.mutex.Unlock()
-
 	return nil
 }
 
+func (t *GrpcConn) closeConn(conn *grpc.ClientConn) {
+	if conn == nil {
+		return
+	}
+	if err := conn.Close(); err != nil {
+		t.logger.Debugf("Failed to close gRPC connection: %s", err)
+	}
+}
+
+// Close closes the connection and stops any Open loop.
 func (t *GrpcConn) Close() error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
+	t.generation++
 	if t.conn != nil {
 		err := t.conn.Close()
 		if err != nil {
@@ -165,4 +218,23 @@ func (t *GrpcConn) GetClient() interface{} {
 	t.mutex.RLock()
 	defer t.mutex.RUnlock()
 	return t.client
-}
\ No newline at end of file
+}
+
+// Opened returns a channel which is closed the next time the connection is
+// (re-)established, so that streams can be resubscribed right away.
+func (t *GrpcConn) Opened() <-chan struct{} {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.opened
+}
+
+// GetState returns the connectivity state of the connection, or Shutdown if
+// it has not been established (yet).
+func (t *GrpcConn) GetState() connectivity.State {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	if t.conn == nil {
+		return connectivity.Shutdown
+	}
+	return t.conn.GetState()
+}
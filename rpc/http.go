@@ -1,5 +1,40 @@
 package rpc
 
+import (
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	"sync"
+)
+
+// HttpConn holds the base URL of an HTTP API whose endpoint can be changed
+// while it is in use.
 type HttpConn struct {
+	url   string
+	mutex *sync.RWMutex
+}
+
+func NewHttpConn(c config.HttpConfig) *HttpConn {
+	return &HttpConn{
+		url:   httpUrl(c),
+		mutex: &sync.RWMutex{},
+	}
+}
+
+func httpUrl(c config.HttpConfig) string {
+	return fmt.Sprintf("http://%s:%d", c.Host, c.Port)
+}
+
+// Update switches to the endpoint in c.
+func (t *HttpConn) Update(c config.HttpConfig) {
+	url := httpUrl(c)
+	t.mutex.Lock()
+	t.url = url
+	t.mutex.Unlock()
+}
 
-}
\ No newline at end of file
+// Url returns the base URL, e.g. http://connext:5040.
+func (t *HttpConn) Url() string {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.url
+}
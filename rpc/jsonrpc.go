@@ -1,5 +1,59 @@
 package rpc
 
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	"github.com/ybbus/jsonrpc"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// JsonrpcConn is a JSON-RPC client whose endpoint can be changed while it is
+// in use.
 type JsonrpcConn struct {
+	client  jsonrpc.RPCClient
+	timeout time.Duration
+	mutex   *sync.RWMutex
+}
+
+// NewJsonrpcConn creates a client for the endpoint in c. A zero timeout means
+// no timeout.
+func NewJsonrpcConn(c config.JsonRpcConfig, timeout time.Duration) *JsonrpcConn {
+	return &JsonrpcConn{
+		client:  newJsonrpcClient(c, timeout),
+		timeout: timeout,
+		mutex:   &sync.RWMutex{},
+	}
+}
+
+func newJsonrpcClient(c config.JsonRpcConfig, timeout time.Duration) jsonrpc.RPCClient {
+	addr := fmt.Sprintf("http://%s:%d", c.Host, c.Port)
+	opts := &jsonrpc.RPCClientOpts{
+		HTTPClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+	if c.Username != "" || c.Password != "" {
+		opts.CustomHeaders = map[string]string{
+			"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(c.Username+":"+c.Password)),
+		}
+	}
+	return jsonrpc.NewClientWithOpts(addr, opts)
+}
+
+// Update switches to the endpoint in c.
+func (t *JsonrpcConn) Update(c config.JsonRpcConfig) {
+	client := newJsonrpcClient(c, t.timeout)
+	t.mutex.Lock()
+	t.client = client
+	t.mutex.Unlock()
+}
 
+func (t *JsonrpcConn) Call(method string, params ...interface{}) (*jsonrpc.RPCResponse, error) {
+	t.mutex.RLock()
+	client := t.client
+	t.mutex.RUnlock()
+	return client.Call(method, params...)
 }
@@ -1,14 +1,14 @@
 package service
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
 	"github.com/ExchangeUnion/xud-docker-api/build"
-	"github.com/ExchangeUnion/xud-docker-api/config"
 	"github.com/ExchangeUnion/xud-docker-api/utils"
 	"github.com/gin-gonic/contrib/static"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"io"
 	"net/http"
 )
@@ -16,55 +16,62 @@ import (
 func (t *Manager) ConfigureRouter(r *gin.Engine) {
 	r.Use(static.Serve("/", static.LocalFile("/ui", false)))
 
+	r.GET("/metrics", auth.Require(auth.ScopeRead), gin.WrapH(promhttp.Handler()))
+
 	api := r.Group("/api")
 	{
-		api.GET("/v1/version", func(c *gin.Context) {
+		api.GET("/v1/version", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 			c.JSON(http.StatusOK, fmt.Sprintf("%s-%s", build.Version, build.GitCommit[:7]))
 		})
-		api.GET("/v1/services", func(c *gin.Context) {
-			var result []ServiceEntry
-
-			result = append(result, ServiceEntry{"xud", "XUD"})
-			result = append(result, ServiceEntry{"lndbtc", "LND (Bitcoin)"})
-			result = append(result, ServiceEntry{"lndltc", "LND (Litecoin)"})
-			result = append(result, ServiceEntry{"connext", "Connext"})
-			result = append(result, ServiceEntry{"bitcoind", "Bitcoind"})
-			result = append(result, ServiceEntry{"litecoind", "Litecoind"})
-			result = append(result, ServiceEntry{"geth", "Geth"})
-			result = append(result, ServiceEntry{"arby", "Arby"})
-			result = append(result, ServiceEntry{"boltz", "Boltz"})
-			result = append(result, ServiceEntry{"webui", "Web UI"})
+		api.GET("/v1/services", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+			c.JSON(http.StatusOK, t.GetServiceEntries())
+		})
+
+		api.GET("/v1/status", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+			status := t.GetStatus()
+
+			var result []ServiceStatus
+
+			for _, name := range t.getStatusNames() {
+				result = append(result, ServiceStatus{Service: name, Status: status[name].Message})
+			}
 
 			c.JSON(http.StatusOK, result)
 		})
 
-		api.GET("/v1/status", func(c *gin.Context) {
+		api.GET("/v1/status/:service", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+			service := c.Param("service")
+			status, err := t.GetServiceStatus(service)
+			if err != nil {
+				utils.JsonError(c, err.Error(), http.StatusNotFound)
+				return
+			}
+			c.JSON(http.StatusOK, ServiceStatus{Service: service, Status: status.Message})
+		})
+
+		api.GET("/v2/status", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 			status := t.GetStatus()
 
-			var result []ServiceStatus
+			var result []ServiceStatusV2
 
-			for _, svc := range t.services {
-				result = append(result, ServiceStatus{Service: svc.GetName(), Status: status[svc.GetName()]})
+			for _, name := range t.getStatusNames() {
+				result = append(result, ServiceStatusV2{Service: name, Status: status[name]})
 			}
 
 			c.JSON(http.StatusOK, result)
 		})
 
-		api.GET("/v1/status/:service", func(c *gin.Context) {
+		api.GET("/v2/status/:service", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 			service := c.Param("service")
-			s, err := t.GetService(service)
+			status, err := t.GetServiceStatus(service)
 			if err != nil {
 				utils.JsonError(c, err.Error(), http.StatusNotFound)
 				return
 			}
-			ctx := context.WithValue(context.Background(), "LauncherState", t.LauncherAgent.GetState())
-			ctx, cancel := context.WithTimeout(ctx, config.DefaultApiTimeout)
-			defer cancel()
-			status := s.GetStatus(ctx)
-			c.JSON(http.StatusOK, ServiceStatus{Service: service, Status: status})
+			c.JSON(http.StatusOK, ServiceStatusV2{Service: service, Status: *status})
 		})
 
-		api.GET("/v1/logs/:service", func(c *gin.Context) {
+		api.GET("/v1/logs/:service", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 			service := c.Param("service")
 			s, err := t.GetService(service)
 			if err != nil {
@@ -88,7 +95,7 @@ func (t *Manager) ConfigureRouter(r *gin.Engine) {
 			}
 		})
 
-		api.GET("/v1/setup-status", func(c *gin.Context) {
+		api.GET("/v1/setup-status", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 
 			statusChan, cancel, history := t.subscribeSetupStatus(-1)
 
@@ -121,7 +128,20 @@ func (t *Manager) ConfigureRouter(r *gin.Engine) {
 		})
 	}
 
-	for _, svc := range t.services {
-		svc.ConfigureRouter(api)
-	}
+	t.watcher.ConfigureRouter(api)
+	t.poller.ConfigureRouter(api)
+	t.configureHistoryRouter(api)
+	t.configureLifecycleRouter(api)
+	t.configureStatsRouter(api)
+	t.configureLogsRouter(api)
+	t.configureReportRouter(api)
+	t.configureSwapsRouter(api)
+	t.configureChannelsRouter(api)
+	t.configurePortfolioRouter(api)
+
+	t.mutex.Lock()
+	t.routed = true
+	t.routes = map[string][]Route{}
+	t.rebuildServiceRouter()
+	t.mutex.Unlock()
 }
@@ -12,34 +12,44 @@ type Service struct {
 	*RpcClient
 }
 
+func init() {
+	core.Register(core.Factory{
+		Name:         "arby",
+		DisplayName:  "Arby",
+		Dependencies: []string{"xud"},
+		New: func(ctx core.FactoryContext, c config.ServiceConfig) (core.Service, error) {
+			return New(c.Name, ctx.Services, ctx.ContainerName, ctx.DockerClient), nil
+		},
+	})
+}
+
 func New(
 	name string,
 	services map[string]core.Service,
 	containerName string,
 	dockerClient *docker.Client,
-	rpcConfig config.RpcConfig,
 ) *Service {
 	return &Service{
 		SingleContainerService: core.NewSingleContainerService(name, services, containerName, dockerClient),
-		RpcClient:              NewRpcClient(rpcConfig),
+		RpcClient:              NewRpcClient(),
 	}
 }
 
-func (t *Service) GetStatus(ctx context.Context) string {
+func (t *Service) GetStatus(ctx context.Context) core.Status {
 	status := t.SingleContainerService.GetStatus(ctx)
-	if status == "Disabled" {
+	if status.State == core.StateDisabled {
 		return status
 	}
-	if status != "Container running" {
+	if !status.IsReady() {
 		if ctx.Value("LauncherState") == "setup" {
-			return "Waiting for sync"
+			return core.NewStatus(core.StateWaiting, "Waiting for sync")
 		}
 		return status
 	}
 
 	// container is running
 
-	return "Ready"
+	return core.NewStatus(core.StateReady, "Ready")
 }
 
 func (t *Service) Close() error {
@@ -1,13 +1,9 @@
 package arby
 
-import (
-	"github.com/ExchangeUnion/xud-docker-api/config"
-)
-
 type RpcClient struct {
 }
 
-func NewRpcClient(config config.RpcConfig) *RpcClient {
+func NewRpcClient() *RpcClient {
 	return &RpcClient{}
 }
 
@@ -25,13 +25,32 @@ const (
 	Light    Mode = "light"
 )
 
+func init() {
+	core.Register(core.Factory{
+		Name:        "bitcoind",
+		DisplayName: "Bitcoind",
+		Rpc:         config.JsonRpc,
+		Commands: []core.ConsoleCommand{
+			{Name: "bitcoin-cli", Description: "bitcoind cli", Command: "bitcoin-cli -rpcuser=xu -rpcpassword=xu $([[ $NETWORK == testnet ]] && echo -testnet)"},
+		},
+		ParseLog: ParseLog,
+		New: func(ctx core.FactoryContext, c config.ServiceConfig) (core.Service, error) {
+			rpc, err := c.JsonRpcConfig()
+			if err != nil {
+				return nil, err
+			}
+			return New(c.Name, ctx.Services, ctx.ContainerName, ctx.DockerClient, "lndbtc", *rpc), nil
+		},
+	})
+}
+
 func New(
 	name string,
 	services map[string]core.Service,
 	containerName string,
 	dockerClient *docker.Client,
 	l2ServiceName string,
-	rpcConfig config.RpcConfig,
+	rpcConfig config.JsonRpcConfig,
 ) *Service {
 	return &Service{
 		SingleContainerService: core.NewSingleContainerService(name, services, containerName, dockerClient),
@@ -77,56 +96,48 @@ func (t *Service) getMode() (Mode, error) {
 	}
 }
 
-func (t *Service) GetStatus(ctx context.Context) string {
+func (t *Service) GetStatus(ctx context.Context) core.Status {
 	mode, err := t.getMode()
 	if err != nil {
-		return fmt.Sprintf("Error: %s", err)
+		return core.ErrorStatus(err)
 	}
 	switch mode {
 	case Native:
 		status := t.SingleContainerService.GetStatus(ctx)
-		if status == "Disabled" {
-			return status
-		}
-		if status != "Container running" {
+		if !status.IsReady() {
 			return status
 		}
 
 		// container is running
 		resp, err := t.GetBlockchainInfo(ctx)
 		if err != nil {
-			return fmt.Sprintf("Waiting for %s to come up...", t.GetName())
+			return core.NewStatus(core.StateStarting, fmt.Sprintf("Waiting for %s to come up...", t.GetName()))
 		}
 		if resp.Error != nil {
 			// Loading block index...
-			return resp.Error.Message
+			return core.NewStatus(core.StateStarting, resp.Error.Message)
 		}
 		r := resp.Result.(map[string]interface{})
 		current, err := r["blocks"].(json.Number).Int64()
 		if err != nil {
-			return fmt.Sprintf("Error: %s", err)
+			return core.ErrorStatus(err)
 		}
 		total, err := r["headers"].(json.Number).Int64()
 		if err != nil {
-			return fmt.Sprintf("Error: %s", err)
+			return core.ErrorStatus(err)
 		}
 		if current > 0 && current == total {
-			return "Ready"
+			return core.NewStatus(core.StateReady, "Ready")
 		} else {
-			if total == 0 {
-				return "Syncing 0.00% (0/0)"
-			} else {
-				p := float32(current) / float32(total) * 100.0
-				return fmt.Sprintf("Syncing %.2f%% (%d/%d)", p, current, total)
-			}
+			return core.SyncingStatus(current, total)
 		}
 	case External:
 		// TODO Unavailable (connection to external failed)
-		return "Ready (connected to external)"
+		return core.NewStatus(core.StateReady, "Ready (connected to external)")
 	case Light:
-		return "Ready (light mode)"
+		return core.NewStatus(core.StateReady, "Ready (light mode)")
 	default:
-		return fmt.Sprintf("Error: unexpect mode: %s", mode)
+		return core.ErrorStatus(fmt.Errorf("unexpect mode: %s", mode))
 	}
 }
 
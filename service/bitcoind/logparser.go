@@ -0,0 +1,34 @@
+package bitcoind
+
+import (
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 2021-01-05T10:00:00Z [msghand] UpdateTip: new best=...
+//
+// The thread name is only there with -logthreadnames. bitcoind has no log
+// levels, so they are derived from the message.
+var logPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?Z) (?:\[([\w.:-]+)\] )?(.*)$`)
+
+func ParseLog(line string) (*core.LogRecord, bool) {
+	m := logPattern.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	level := core.LevelInfo
+	lower := strings.ToLower(m[3])
+	if strings.HasPrefix(lower, "error") || strings.Contains(lower, " error: ") {
+		level = core.LevelError
+	} else if strings.HasPrefix(lower, "warning") {
+		level = core.LevelWarn
+	}
+	return &core.LogRecord{
+		Timestamp: core.ParseTime(time.RFC3339, m[1]),
+		Level:     level,
+		Subsystem: m[2],
+		Message:   m[3],
+	}, true
+}
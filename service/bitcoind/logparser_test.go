@@ -0,0 +1,47 @@
+package bitcoind
+
+import (
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"testing"
+)
+
+func TestParseLog(t *testing.T) {
+	tests := []struct {
+		line string
+		want *core.LogRecord
+	}{
+		{
+			line: "2021-01-05T10:00:00Z UpdateTip: new best=00000000",
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelInfo, Message: "UpdateTip: new best=00000000"},
+		},
+		{
+			line: "2021-01-05T10:00:00Z [msghand] ERROR: AcceptBlock: bad block",
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelError, Subsystem: "msghand", Message: "ERROR: AcceptBlock: bad block"},
+		},
+		{
+			line: "2021-01-05T10:00:00.123456Z Warning: unknown new rules activated",
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelWarn, Message: "Warning: unknown new rules activated"},
+		},
+		{
+			line: "2021-01-05T10:00:00Z ProcessMessages(block) error: peer=1",
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelError, Message: "ProcessMessages(block) error: peer=1"},
+		},
+		{line: "Bitcoin Core version v0.20.1"},
+	}
+	for _, test := range tests {
+		got, ok := ParseLog(test.line)
+		if test.want == nil {
+			if ok {
+				t.Errorf("%q: got %+v, want no record", test.line, *got)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%q: got no record, want %+v", test.line, *test.want)
+			continue
+		}
+		if *got != *test.want {
+			t.Errorf("%q: got %+v, want %+v", test.line, *got, *test.want)
+		}
+	}
+}
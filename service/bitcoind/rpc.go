@@ -2,12 +2,10 @@ package bitcoind
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
-	"fmt"
 	"github.com/ExchangeUnion/xud-docker-api/config"
+	"github.com/ExchangeUnion/xud-docker-api/rpc"
 	"github.com/ybbus/jsonrpc"
-	"net/http"
 	"time"
 )
 
@@ -38,26 +36,23 @@ type BlockchainInfo struct {
 }
 
 type RpcClient struct {
-	client jsonrpc.RPCClient
+	conn *rpc.JsonrpcConn
 }
 
-func NewRpcClient(config config.RpcConfig) *RpcClient {
-	host := config["host"].(string)
-	port := uint16(config["port"].(float64))
-
-	addr := fmt.Sprintf("http://%s:%d", host, port)
-	client := jsonrpc.NewClientWithOpts(addr, &jsonrpc.RPCClientOpts{
-		HTTPClient: &http.Client{
-			Timeout: HttpRequestTimeout,
-		},
-		CustomHeaders: map[string]string{
-			"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("xu"+":"+"xu")),
-		},
-	})
-
+func NewRpcClient(config config.JsonRpcConfig) *RpcClient {
 	return &RpcClient{
-		client: client,
+		conn: rpc.NewJsonrpcConn(config, HttpRequestTimeout),
+	}
+}
+
+// Reconfigure applies a changed rpc block of config.json.
+func (t *RpcClient) Reconfigure(c config.ServiceConfig) error {
+	rpcConfig, err := c.JsonRpcConfig()
+	if err != nil {
+		return err
 	}
+	t.conn.Update(*rpcConfig)
+	return nil
 }
 
 func (t *RpcClient) Close() error {
@@ -70,7 +65,7 @@ func (t *RpcClient) GetBlockchainInfo(ctx context.Context) (*jsonrpc.RPCResponse
 	case <-ctx.Done():
 		return nil, errors.New("cancelled by context")
 	default:
-		response, err := t.client.Call("getblockchaininfo")
+		response, err := t.conn.Call("getblockchaininfo")
 		if err != nil {
 			return nil, err
 		}
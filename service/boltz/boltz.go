@@ -20,12 +20,32 @@ const (
 	LTC Node = "ltc"
 )
 
+func init() {
+	core.Register(core.Factory{
+		Name:         "boltz",
+		DisplayName:  "Boltz",
+		Rpc:          config.Boltz,
+		Dependencies: []string{"lndbtc", "lndltc"},
+		Commands: []core.ConsoleCommand{
+			{Name: "boltzcli", Description: "boltz cli", Command: "wrapper"},
+		},
+		ParseLog: ParseLog,
+		New: func(ctx core.FactoryContext, c config.ServiceConfig) (core.Service, error) {
+			rpc, err := c.BoltzConfig()
+			if err != nil {
+				return nil, err
+			}
+			return New(c.Name, ctx.Services, ctx.ContainerName, ctx.DockerClient, *rpc), nil
+		},
+	})
+}
+
 func New(
 	name string,
 	services map[string]core.Service,
 	containerName string,
 	dockerClient *docker.Client,
-	rpcConfig config.RpcConfig,
+	rpcConfig config.BoltzConfig,
 ) *Service {
 	base := core.NewSingleContainerService(name, services, containerName, dockerClient)
 
@@ -69,14 +89,14 @@ func (t *Service) checkNode(node Node) NodeStatus {
 	}
 }
 
-func (t *Service) GetStatus(ctx context.Context) string {
+func (t *Service) GetStatus(ctx context.Context) core.Status {
 	status := t.SingleContainerService.GetStatus(ctx)
-	if status == "Disabled" {
+	if status.State == core.StateDisabled {
 		return status
 	}
-	if status != "Container running" {
+	if !status.IsReady() {
 		if ctx.Value("LauncherState") == "setup" {
-			return "Waiting for sync"
+			return core.NewStatus(core.StateWaiting, "Waiting for sync")
 		}
 		return status
 	}
@@ -87,9 +107,9 @@ func (t *Service) GetStatus(ctx context.Context) string {
 	ltcStatus := t.checkNode(LTC)
 
 	if btcStatus.IsUp && ltcStatus.IsUp {
-		return "Ready"
+		return core.NewStatus(core.StateReady, "Ready")
 	} else {
-		return btcStatus.Status + "; " + ltcStatus.Status
+		return core.NewStatus(core.StateError, btcStatus.Status+"; "+ltcStatus.Status)
 	}
 }
 
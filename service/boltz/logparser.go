@@ -0,0 +1,32 @@
+package boltz
+
+import (
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"regexp"
+)
+
+var (
+	// INFO : 2021/01/05 10:00:00.123456 main.go:42: message
+	logPattern = regexp.MustCompile(`^(INFO|WARNING|ERROR|FATAL)\s*: (\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?) (?:([\w.-]+\.go:\d+): )?(.*)$`)
+	// 2021/01/05 10:00:00 message
+	plainLogPattern = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?) (.*)$`)
+)
+
+func ParseLog(line string) (*core.LogRecord, bool) {
+	if m := logPattern.FindStringSubmatch(line); m != nil {
+		return &core.LogRecord{
+			Timestamp: core.ParseTime("2006/01/02 15:04:05", m[2]),
+			Level:     core.NormalizeLevel(m[1]),
+			Subsystem: m[3],
+			Message:   m[4],
+		}, true
+	}
+	if m := plainLogPattern.FindStringSubmatch(line); m != nil {
+		return &core.LogRecord{
+			Timestamp: core.ParseTime("2006/01/02 15:04:05", m[1]),
+			Level:     core.LevelInfo,
+			Message:   m[2],
+		}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,43 @@
+package boltz
+
+import (
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"testing"
+)
+
+func TestParseLog(t *testing.T) {
+	tests := []struct {
+		line string
+		want *core.LogRecord
+	}{
+		{
+			line: "ERROR : 2021/01/05 10:00:00.123456 main.go:42: could not connect",
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelError, Subsystem: "main.go:42", Message: "could not connect"},
+		},
+		{
+			line: "WARNING: 2021/01/05 10:00:00 swap is pending",
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelWarn, Message: "swap is pending"},
+		},
+		{
+			line: "2021/01/05 10:00:00 Starting boltz-lnd",
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelInfo, Message: "Starting boltz-lnd"},
+		},
+		{line: "panic: runtime error"},
+	}
+	for _, test := range tests {
+		got, ok := ParseLog(test.line)
+		if test.want == nil {
+			if ok {
+				t.Errorf("%q: got %+v, want no record", test.line, *got)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%q: got no record, want %+v", test.line, *test.want)
+			continue
+		}
+		if *got != *test.want {
+			t.Errorf("%q: got %+v, want %+v", test.line, *got, *test.want)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/ExchangeUnion/xud-docker-api/service/core"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"strings"
 )
 
@@ -25,26 +26,18 @@ type RpcClient struct {
 	service *core.SingleContainerService
 }
 
-func newGrpcConn(config map[string]interface{}, logger *logrus.Entry) *rpc.GrpcConn {
-	host := config["host"].(string)
-	port := uint16(config["port"].(float64))
-	tlsCert := config["tlsCert"].(string)
-	macaroon := config["macaroon"].(string)
-
-	conn := rpc.NewGrpcConn(host, port, tlsCert, macaroon, logger, func(conn *grpc.ClientConn) interface{} {
+func newGrpcConn(config config.GrpcConfig, logger *logrus.Entry) *rpc.GrpcConn {
+	conn := rpc.NewGrpcConn(config.Host, config.Port, config.TlsCert, config.Macaroon, logger, func(conn *grpc.ClientConn) interface{} {
 		return pb.NewBoltzClient(conn)
 	})
 	return conn
 }
 
-func NewRpcClient(config config.RpcConfig, service *core.SingleContainerService) *RpcClient {
-	bitcoin := config["bitcoin"].(map[string]interface{})
-	litecoin := config["litecoin"].(map[string]interface{})
-
+func NewRpcClient(config config.BoltzConfig, service *core.SingleContainerService) *RpcClient {
 	logger := service.GetLogger().WithField("name", fmt.Sprintf("service.%s.rpc", service.GetName()))
 
-	btcConn := newGrpcConn(bitcoin, logger)
-	ltcConn := newGrpcConn(litecoin, logger)
+	btcConn := newGrpcConn(config.Bitcoin, logger)
+	ltcConn := newGrpcConn(config.Litecoin, logger)
 
 	go btcConn.Open()
 	go ltcConn.Open()
@@ -69,14 +62,34 @@ func (t *RpcClient) Close() error {
 	return nil
 }
 
+func (t *RpcClient) GetGrpcStates() map[string]connectivity.State {
+	return map[string]connectivity.State{
+		"bitcoin":  t.btcConn.GetState(),
+		"litecoin": t.ltcConn.GetState(),
+	}
+}
+
+// Reconfigure applies a changed rpc block of config.json. Only the bitcoin
+// and litecoin connections whose endpoint has changed are reopened.
+func (t *RpcClient) Reconfigure(c config.ServiceConfig) error {
+	rpcConfig, err := c.BoltzConfig()
+	if err != nil {
+		return err
+	}
+	b, l := rpcConfig.Bitcoin, rpcConfig.Litecoin
+	t.btcConn.Update(b.Host, b.Port, b.TlsCert, b.Macaroon)
+	t.ltcConn.Update(l.Host, l.Port, l.TlsCert, l.Macaroon)
+	return nil
+}
+
 func (t *RpcClient) getRpcClient(currency string) (pb.BoltzClient, error) {
 	currency = strings.ToLower(currency)
 	var client pb.BoltzClient
 	switch currency {
 	case "btc":
-		client = t.btcConn.GetClient().(pb.BoltzClient)
+		client, _ = t.btcConn.GetClient().(pb.BoltzClient)
 	case "ltc":
-		client = t.ltcConn.GetClient().(pb.BoltzClient)
+		client, _ = t.ltcConn.GetClient().(pb.BoltzClient)
 	default:
 		panic(errors.New("invalid currency: " + currency))
 	}
@@ -115,3 +128,23 @@ func (t *RpcClient) Withdraw(ctx context.Context, currency string, amount int64,
 	req.Address = address
 	return client.CreateReverseSwap(ctx, &req)
 }
+
+// GetClientInfo returns the block height and the pending swaps of the
+// boltz-client of currency.
+func (t *RpcClient) GetClientInfo(ctx context.Context, currency string) (*pb.GetInfoResponse, error) {
+	client, err := t.getRpcClient(currency)
+	if err != nil {
+		return nil, err
+	}
+	req := pb.GetInfoRequest{}
+	return client.GetInfo(ctx, &req)
+}
+
+func (t *RpcClient) ListSwaps(ctx context.Context, currency string) (*pb.ListSwapsResponse, error) {
+	client, err := t.getRpcClient(currency)
+	if err != nil {
+		return nil, err
+	}
+	req := pb.ListSwapsRequest{}
+	return client.ListSwaps(ctx, &req)
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	"github.com/ExchangeUnion/xud-docker-api/service/lnd"
+	"github.com/ExchangeUnion/xud-docker-api/service/lnd/lnrpc"
+	"github.com/ExchangeUnion/xud-docker-api/service/xud"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+const (
+	ChannelActive       = "active"
+	ChannelInactive     = "inactive"
+	ChannelPendingOpen  = "pending_open"
+	ChannelWaitingClose = "waiting_close"
+	ChannelForceClosing = "force_closing"
+)
+
+// Channel is a payment channel of lnd. Amounts are in satoshis.
+type Channel struct {
+	State         string `json:"state"`
+	RemotePubkey  string `json:"remotePubkey"`
+	ChannelPoint  string `json:"channelPoint"`
+	ChanId        uint64 `json:"chanId,omitempty"`
+	Capacity      int64  `json:"capacity"`
+	LocalBalance  int64  `json:"localBalance"`
+	RemoteBalance int64  `json:"remoteBalance"`
+	ClosingTxid   string `json:"closingTxid,omitempty"`
+	// BlocksTilMaturity is the number of blocks until the funds of a force
+	// closed channel can be swept
+	BlocksTilMaturity int32 `json:"blocksTilMaturity,omitempty"`
+}
+
+type ChannelSummary struct {
+	Active   uint32 `json:"active"`
+	Inactive uint32 `json:"inactive"`
+	Pending  uint32 `json:"pending"`
+	Closed   uint32 `json:"closed"`
+}
+
+type ChannelBalance struct {
+	Channel         uint64 `json:"channel"`
+	PendingChannel  uint64 `json:"pendingChannel"`
+	InactiveChannel uint64 `json:"inactiveChannel"`
+	Wallet          uint64 `json:"wallet"`
+}
+
+// CurrencyChannels combines what xud knows about the channels of a currency
+// with the channels of the lnd behind it. Status is what xud reports about
+// the swap client and ServiceStatus the status of the service itself.
+// Connext currencies have no channel list. Errors lists the parts of this
+// currency which couldn't be fetched.
+type CurrencyChannels struct {
+	Currency      string          `json:"currency"`
+	SwapClient    string          `json:"swapClient"`
+	Service       string          `json:"service"`
+	Status        string          `json:"status"`
+	ServiceStatus string          `json:"serviceStatus"`
+	Summary       *ChannelSummary `json:"summary,omitempty"`
+	Balance       *ChannelBalance `json:"balance,omitempty"`
+	Channels      []Channel       `json:"channels,omitempty"`
+	Errors        []string        `json:"errors,omitempty"`
+}
+
+// ChannelsOverview is the response of /v1/channels. Errors lists what
+// couldn't be fetched from xud, which concerns all currencies.
+type ChannelsOverview struct {
+	Currencies []CurrencyChannels `json:"currencies"`
+	Errors     []string           `json:"errors,omitempty"`
+}
+
+func newPendingChannel(state string, c *lnrpc.PendingChannelsResponse_PendingChannel) Channel {
+	return Channel{
+		State:         state,
+		RemotePubkey:  c.RemoteNodePub,
+		ChannelPoint:  c.ChannelPoint,
+		Capacity:      c.Capacity,
+		LocalBalance:  c.LocalBalance,
+		RemoteBalance: c.RemoteBalance,
+	}
+}
+
+func listLndChannels(ctx context.Context, s *lnd.Service) ([]Channel, error) {
+	result := []Channel{}
+
+	channels, err := s.ListChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range channels.Channels {
+		state := ChannelInactive
+		if c.Active {
+			state = ChannelActive
+		}
+		result = append(result, Channel{
+			State:         state,
+			RemotePubkey:  c.RemotePubkey,
+			ChannelPoint:  c.ChannelPoint,
+			ChanId:        c.ChanId,
+			Capacity:      c.Capacity,
+			LocalBalance:  c.LocalBalance,
+			RemoteBalance: c.RemoteBalance,
+		})
+	}
+
+	pending, err := s.PendingChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range pending.PendingOpenChannels {
+		result = append(result, newPendingChannel(ChannelPendingOpen, c.Channel))
+	}
+	for _, c := range pending.WaitingCloseChannels {
+		result = append(result, newPendingChannel(ChannelWaitingClose, c.Channel))
+	}
+	for _, c := range pending.PendingForceClosingChannels {
+		channel := newPendingChannel(ChannelForceClosing, c.Channel)
+		channel.ClosingTxid = c.ClosingTxid
+		channel.BlocksTilMaturity = c.BlocksTilMaturity
+		result = append(result, channel)
+	}
+
+	return result, nil
+}
+
+// GetChannels returns the channels of every currency xud trades.
+func (t *Manager) GetChannels(ctx context.Context) (*ChannelsOverview, error) {
+	s, err := t.GetService("xud")
+	if err != nil {
+		return nil, err
+	}
+	x, ok := s.(*xud.Service)
+	if !ok {
+		return nil, errors.New("xud is not available")
+	}
+
+	currencies, err := x.ListCurrencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// both are optional, a locked xud still lists its currencies
+	info, infoErr := x.GetInfo(ctx)
+	balances, balanceErr := x.GetBalance(ctx, "")
+
+	result := &ChannelsOverview{Currencies: []CurrencyChannels{}}
+	if infoErr != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("xud info: %s", infoErr))
+	}
+	if balanceErr != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("xud balance: %s", balanceErr))
+	}
+
+	status := t.GetStatus()
+	for _, c := range currencies.Currencies {
+		item := CurrencyChannels{
+			Currency:   c.Currency,
+			SwapClient: c.SwapClient.String(),
+		}
+
+		if balanceErr == nil {
+			if b, ok := balances.Balances[c.Currency]; ok {
+				item.Balance = &ChannelBalance{
+					Channel:         b.ChannelBalance,
+					PendingChannel:  b.PendingChannelBalance,
+					InactiveChannel: b.InactiveChannelBalance,
+					Wallet:          b.WalletBalance,
+				}
+			}
+		}
+
+		if item.SwapClient == "CONNEXT" {
+			item.Service = "connext"
+			item.ServiceStatus = status[item.Service].Message
+			if infoErr == nil && info.Connext != nil {
+				item.Status = info.Connext.Status
+			}
+			result.Currencies = append(result.Currencies, item)
+			continue
+		}
+
+		item.Service = "lnd" + strings.ToLower(c.Currency)
+		item.ServiceStatus = status[item.Service].Message
+		if infoErr == nil {
+			if l, ok := info.Lnd[c.Currency]; ok {
+				item.Status = l.Status
+				if l.Channels != nil {
+					item.Summary = &ChannelSummary{
+						Active:   l.Channels.Active,
+						Inactive: l.Channels.Inactive,
+						Pending:  l.Channels.Pending,
+						Closed:   l.Channels.Closed,
+					}
+				}
+			}
+		}
+		if s, err := t.GetService(item.Service); err != nil {
+			item.Errors = append(item.Errors, err.Error())
+		} else if l, ok := s.(*lnd.Service); !ok {
+			item.Errors = append(item.Errors, fmt.Sprintf("%s is not an lnd service", item.Service))
+		} else if channels, err := listLndChannels(ctx, l); err != nil {
+			item.Errors = append(item.Errors, fmt.Sprintf("%s channels: %s", item.Service, err))
+		} else {
+			item.Channels = channels
+		}
+		result.Currencies = append(result.Currencies, item)
+	}
+
+	return result, nil
+}
+
+func (t *Manager) configureChannelsRouter(r *gin.RouterGroup) {
+	r.GET("/v1/channels", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		channels, err := t.GetChannels(ctx)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, channels)
+	})
+}
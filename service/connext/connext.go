@@ -14,12 +14,29 @@ type Service struct {
 	*RpcClient
 }
 
+func init() {
+	core.Register(core.Factory{
+		Name:         "connext",
+		DisplayName:  "Connext",
+		Rpc:          config.Http,
+		Dependencies: []string{"geth"},
+		ParseLog:     ParseLog,
+		New: func(ctx core.FactoryContext, c config.ServiceConfig) (core.Service, error) {
+			rpc, err := c.HttpConfig()
+			if err != nil {
+				return nil, err
+			}
+			return New(c.Name, ctx.Services, ctx.ContainerName, ctx.DockerClient, *rpc), nil
+		},
+	})
+}
+
 func New(
 	name string,
 	services map[string]core.Service,
 	containerName string,
 	dockerClient *docker.Client,
-	rpcConfig config.RpcConfig,
+	rpcConfig config.HttpConfig,
 ) *Service {
 	base := core.NewSingleContainerService(name, services, containerName, dockerClient)
 
@@ -29,12 +46,9 @@ func New(
 	}
 }
 
-func (t *Service) GetStatus(ctx context.Context) string {
+func (t *Service) GetStatus(ctx context.Context) core.Status {
 	status := t.SingleContainerService.GetStatus(ctx)
-	if status == "Disabled" {
-		return status
-	}
-	if status != "Container running" {
+	if !status.IsReady() {
 		return status
 	}
 
@@ -45,14 +59,18 @@ func (t *Service) GetStatus(ctx context.Context) string {
 		xudSvc := svc.(*xud.Service)
 		info, err := xudSvc.GetInfo(ctx)
 		if err == nil {
-			return info.Connext.Status
+			// xud reports the connext status as free-form text
+			if info.Connext.Status == "Ready" {
+				return core.NewStatus(core.StateReady, info.Connext.Status)
+			}
+			return core.NewStatus(core.StateWaiting, info.Connext.Status)
 		}
 	}
 
 	if t.IsHealthy(ctx) {
-		return "Ready"
+		return core.NewStatus(core.StateReady, "Ready")
 	} else {
-		return "Starting..."
+		return core.NewStatus(core.StateStarting, "Starting...")
 	}
 }
 
@@ -0,0 +1,50 @@
+package connext
+
+import (
+	"encoding/json"
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"strings"
+)
+
+// connext logs JSON objects with pino's numeric levels, e.g.
+// {"level":30,"time":1609840800000,"context":"ChannelService","msg":"..."}
+type logLine struct {
+	Level   int    `json:"level"`
+	Time    int64  `json:"time"`
+	Context string `json:"context"`
+	Module  string `json:"module"`
+	Name    string `json:"name"`
+	Msg     string `json:"msg"`
+}
+
+var pinoLevels = map[int]string{
+	10: core.LevelTrace,
+	20: core.LevelDebug,
+	30: core.LevelInfo,
+	40: core.LevelWarn,
+	50: core.LevelError,
+	60: core.LevelFatal,
+}
+
+func ParseLog(line string) (*core.LogRecord, bool) {
+	if !strings.HasPrefix(line, "{") {
+		return nil, false
+	}
+	var l logLine
+	if err := json.Unmarshal([]byte(line), &l); err != nil {
+		return nil, false
+	}
+	subsystem := l.Context
+	if subsystem == "" {
+		subsystem = l.Module
+	}
+	if subsystem == "" {
+		subsystem = l.Name
+	}
+	return &core.LogRecord{
+		Timestamp: l.Time / 1000,
+		Level:     pinoLevels[l.Level],
+		Subsystem: subsystem,
+		Message:   l.Msg,
+	}, true
+}
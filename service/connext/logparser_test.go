@@ -0,0 +1,44 @@
+package connext
+
+import (
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"testing"
+)
+
+func TestParseLog(t *testing.T) {
+	tests := []struct {
+		line string
+		want *core.LogRecord
+	}{
+		{
+			line: `{"level":30,"time":1609840800123,"context":"ChannelService","msg":"channel created"}`,
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelInfo, Subsystem: "ChannelService", Message: "channel created"},
+		},
+		{
+			line: `{"level":50,"time":1609840800000,"module":"Node","msg":"failed"}`,
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelError, Subsystem: "Node", Message: "failed"},
+		},
+		{
+			line: `{"level":40,"time":1609840800000,"name":"indra","msg":"slow"}`,
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelWarn, Subsystem: "indra", Message: "slow"},
+		},
+		{line: `{"level":`},
+		{line: "Starting node"},
+	}
+	for _, test := range tests {
+		got, ok := ParseLog(test.line)
+		if test.want == nil {
+			if ok {
+				t.Errorf("%q: got %+v, want no record", test.line, *got)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%q: got no record, want %+v", test.line, *test.want)
+			continue
+		}
+		if *got != *test.want {
+			t.Errorf("%q: got %+v, want %+v", test.line, *got, *test.want)
+		}
+	}
+}
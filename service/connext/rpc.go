@@ -4,35 +4,41 @@ import (
 	"context"
 	"fmt"
 	"github.com/ExchangeUnion/xud-docker-api/config"
+	"github.com/ExchangeUnion/xud-docker-api/rpc"
 	"github.com/ExchangeUnion/xud-docker-api/service/core"
 	"github.com/sirupsen/logrus"
 	"net/http"
 )
 
 type RpcClient struct {
-	url            string
-	healthEndpoint string
-	client         *http.Client
+	conn   *rpc.HttpConn
+	client *http.Client
 
 	logger  *logrus.Entry
 	service *core.SingleContainerService
 }
 
-func NewRpcClient(config config.RpcConfig, service *core.SingleContainerService) *RpcClient {
-	host := config["host"].(string)
-	port := uint16(config["port"].(float64))
-	url := fmt.Sprintf("http://%s:%d", host, port)
+func NewRpcClient(config config.HttpConfig, service *core.SingleContainerService) *RpcClient {
 	return &RpcClient{
-		url:            url,
-		healthEndpoint: fmt.Sprintf("%s/health", url),
-		client:         &http.Client{},
-		logger:         service.GetLogger().WithField("name", fmt.Sprintf("service.%s.rpc", service.GetName())),
-		service:        service,
+		conn:    rpc.NewHttpConn(config),
+		client:  &http.Client{},
+		logger:  service.GetLogger().WithField("name", fmt.Sprintf("service.%s.rpc", service.GetName())),
+		service: service,
 	}
 }
 
+// Reconfigure applies a changed rpc block of config.json.
+func (t *RpcClient) Reconfigure(c config.ServiceConfig) error {
+	rpcConfig, err := c.HttpConfig()
+	if err != nil {
+		return err
+	}
+	t.conn.Update(*rpcConfig)
+	return nil
+}
+
 func (t *RpcClient) IsHealthy(ctx context.Context) bool {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.healthEndpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.conn.Url()+"/health", nil)
 	if err != nil {
 		t.logger.Errorf("Failed to create HTTP request: %s", err)
 		return false
@@ -2,8 +2,11 @@ package core
 
 import (
 	"context"
+	"github.com/ExchangeUnion/xud-docker-api/config"
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/connectivity"
 	"io"
+	"time"
 )
 
 type DockerEventListener interface {
@@ -17,8 +20,10 @@ type Service interface {
 	ConfigureRouter(r *gin.RouterGroup)
 
 	GetName() string
-	GetStatus(ctx context.Context) string
+	GetStatus(ctx context.Context) Status
 	GetContainerId() string
+	GetContainerName() string
+	GetImage() string
 	IsDisabled() bool
 	SetDisabled(value bool)
 	GetMode() string
@@ -27,3 +32,37 @@ type Service interface {
 	GetLogs(since string, tail string) ([]string, error)
 	FollowLogs(since string, tail string) (<-chan string, func(), error)
 }
+
+// LogFollower is implemented by services whose logs can be followed across
+// container restarts.
+type LogFollower interface {
+	FollowLogs2(since string, tail string) (<-chan string, func(), error)
+}
+
+// Reconfigurable is implemented by services which can apply a changed
+// config.json entry without being recreated.
+type Reconfigurable interface {
+	Reconfigure(c config.ServiceConfig) error
+}
+
+// GrpcService is implemented by services which talk to their container over
+// gRPC, so that the state of the connections can be monitored.
+type GrpcService interface {
+	GetGrpcStates() map[string]connectivity.State
+}
+
+// ContainerController is implemented by services backed by a container which
+// can be started and stopped through the API.
+type ContainerController interface {
+	StartContainer(ctx context.Context) error
+	StopContainer(ctx context.Context, timeout time.Duration) error
+	RestartContainer(ctx context.Context, timeout time.Duration) error
+	RecreateContainer(ctx context.Context, timeout time.Duration) error
+}
+
+// StatsProvider is implemented by services backed by a container whose
+// resource usage can be reported.
+type StatsProvider interface {
+	GetStats(ctx context.Context) (*ContainerStats, error)
+	StreamStats(ctx context.Context) (<-chan ContainerStats, error)
+}
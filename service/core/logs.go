@@ -0,0 +1,117 @@
+package core
+
+import (
+	"strings"
+	"time"
+)
+
+// LogRecord is a parsed log line. Lines which don't start a new record (e.g.
+// stack traces) are appended to the message of the previous one.
+type LogRecord struct {
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Level     string `json:"level,omitempty"`
+	Subsystem string `json:"subsystem,omitempty"`
+	Message   string `json:"message"`
+}
+
+// LogParser parses a single log line. It returns false if the line does not
+// start a new record.
+type LogParser func(line string) (*LogRecord, bool)
+
+const (
+	LevelTrace = "trace"
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+	LevelFatal = "fatal"
+)
+
+var (
+	levels = []string{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal}
+
+	// levelAliases maps the level names of the services to the ones above
+	levelAliases = map[string]string{
+		"trc":      LevelTrace,
+		"trce":     LevelTrace,
+		"trace":    LevelTrace,
+		"dbg":      LevelDebug,
+		"dbug":     LevelDebug,
+		"debug":    LevelDebug,
+		"verbose":  LevelDebug,
+		"inf":      LevelInfo,
+		"info":     LevelInfo,
+		"wrn":      LevelWarn,
+		"warn":     LevelWarn,
+		"warning":  LevelWarn,
+		"err":      LevelError,
+		"eror":     LevelError,
+		"error":    LevelError,
+		"crt":      LevelFatal,
+		"crit":     LevelFatal,
+		"critical": LevelFatal,
+		"fatal":    LevelFatal,
+		"panic":    LevelFatal,
+	}
+)
+
+// NormalizeLevel maps a level name such as "ERR", "EROR" or "warning" to one
+// of the Level constants. It returns an empty string for unknown names.
+func NormalizeLevel(level string) string {
+	return levelAliases[strings.ToLower(strings.TrimSpace(level))]
+}
+
+// LevelAtLeast reports whether level is as severe as min. Both must be
+// normalized.
+func LevelAtLeast(level string, min string) bool {
+	var l, m = -1, -1
+	for i, item := range levels {
+		if item == level {
+			l = i
+		}
+		if item == min {
+			m = i
+		}
+	}
+	return l >= 0 && l >= m
+}
+
+// ParseTime parses value in the given layout as UTC, which is the time zone
+// of the containers. It returns 0 if value does not match.
+func ParseTime(layout string, value string) int64 {
+	t, err := time.ParseInLocation(layout, value, time.UTC)
+	if err != nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+// ParseLogs turns lines into records using parser. Without a parser every
+// line becomes a record of its own with only the message set.
+func ParseLogs(lines []string, parser LogParser) []LogRecord {
+	var result []LogRecord
+	for _, line := range lines {
+		if parser == nil {
+			result = append(result, LogRecord{Message: line})
+			continue
+		}
+		if r, ok := parser(line); ok {
+			result = append(result, *r)
+			continue
+		}
+		if len(result) > 0 {
+			last := &result[len(result)-1]
+			last.Message = last.Message + "\n" + line
+		} else {
+			result = append(result, LogRecord{Message: line})
+		}
+	}
+	return result
+}
+
+func GetLogParser(name string) LogParser {
+	if f, ok := GetFactory(name); ok {
+		return f.ParseLog
+	}
+	return nil
+}
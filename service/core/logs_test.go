@@ -0,0 +1,94 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeLevel(t *testing.T) {
+	tests := []struct {
+		level string
+		want  string
+	}{
+		{"TRC", LevelTrace},
+		{"dbug", LevelDebug},
+		{"verbose", LevelDebug},
+		{"INFO", LevelInfo},
+		{" warning ", LevelWarn},
+		{"EROR", LevelError},
+		{"CRIT", LevelFatal},
+		{"panic", LevelFatal},
+		{"notice", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := NormalizeLevel(test.level); got != test.want {
+			t.Errorf("NormalizeLevel(%q) = %q, want %q", test.level, got, test.want)
+		}
+	}
+}
+
+func TestLevelAtLeast(t *testing.T) {
+	tests := []struct {
+		level string
+		min   string
+		want  bool
+	}{
+		{LevelError, LevelWarn, true},
+		{LevelWarn, LevelWarn, true},
+		{LevelInfo, LevelWarn, false},
+		{LevelFatal, LevelTrace, true},
+		{"", LevelTrace, false},
+		{LevelInfo, "", true},
+	}
+	for _, test := range tests {
+		if got := LevelAtLeast(test.level, test.min); got != test.want {
+			t.Errorf("LevelAtLeast(%q, %q) = %v, want %v", test.level, test.min, got, test.want)
+		}
+	}
+}
+
+func TestParseLogs(t *testing.T) {
+	parser := func(line string) (*LogRecord, bool) {
+		if len(line) > 0 && line[0] == '>' {
+			return &LogRecord{Level: LevelInfo, Message: line[1:]}, true
+		}
+		return nil, false
+	}
+	tests := []struct {
+		name   string
+		lines  []string
+		parser LogParser
+		want   []LogRecord
+	}{
+		{
+			name:   "continuation lines",
+			lines:  []string{">a", "  at x", "  at y", ">b"},
+			parser: parser,
+			want: []LogRecord{
+				{Level: LevelInfo, Message: "a\n  at x\n  at y"},
+				{Level: LevelInfo, Message: "b"},
+			},
+		},
+		{
+			name:   "leading continuation line",
+			lines:  []string{"  at x", ">a"},
+			parser: parser,
+			want: []LogRecord{
+				{Message: "  at x"},
+				{Level: LevelInfo, Message: "a"},
+			},
+		},
+		{
+			name:   "no parser",
+			lines:  []string{">a", "b"},
+			parser: nil,
+			want:   []LogRecord{{Message: ">a"}, {Message: "b"}},
+		},
+	}
+	for _, test := range tests {
+		if got := ParseLogs(test.lines, test.parser); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
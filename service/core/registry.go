@@ -0,0 +1,151 @@
+package core
+
+import (
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	docker "github.com/docker/docker/client"
+	"sort"
+	"sync"
+)
+
+// FactoryContext carries everything a Factory needs besides the service's own
+// config.json entry.
+type FactoryContext struct {
+	Network       string
+	ContainerName string
+	Services      map[string]Service
+	DockerClient  *docker.Client
+}
+
+// ConsoleCommand is a CLI exposed in the web console. Command is executed
+// inside the service container with the user's arguments appended.
+type ConsoleCommand struct {
+	Name        string
+	Description string
+	Command     string
+}
+
+type Factory struct {
+	// Name is the service name used in config.json
+	Name        string
+	DisplayName string
+	Rpc         config.RpcKind
+	// Dependencies are the services this service relies on
+	Dependencies []string
+	Commands     []ConsoleCommand
+	// ParseLog parses the log lines of the service container
+	ParseLog LogParser
+	New      func(ctx FactoryContext, c config.ServiceConfig) (Service, error)
+}
+
+var (
+	factories     = map[string]*Factory{}
+	factoryOrder  []string
+	registryMutex = &sync.RWMutex{}
+)
+
+// Register makes a service available to the Manager. It is meant to be called
+// from the init function of a service package, so adding a service only
+// requires importing its package.
+func Register(f Factory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	if _, ok := factories[f.Name]; ok {
+		panic(fmt.Sprintf("service %s registered twice", f.Name))
+	}
+	factories[f.Name] = &f
+	factoryOrder = append(factoryOrder, f.Name)
+	config.RegisterService(f.Name, f.Rpc)
+}
+
+func GetFactory(name string) (*Factory, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	f, ok := factories[name]
+	return f, ok
+}
+
+// GetFactories returns all registered factories in registration order.
+func GetFactories() []*Factory {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	var result []*Factory
+	for _, name := range factoryOrder {
+		result = append(result, factories[name])
+	}
+	return result
+}
+
+func GetDisplayName(name string) string {
+	if f, ok := GetFactory(name); ok && f.DisplayName != "" {
+		return f.DisplayName
+	}
+	return name
+}
+
+func GetDependencies(name string) []string {
+	if f, ok := GetFactory(name); ok {
+		return f.Dependencies
+	}
+	return nil
+}
+
+// SortByDependencies orders names so that every service comes after the
+// services it depends on. Dependencies outside names are ignored and the
+// original order is kept where possible.
+func SortByDependencies(names []string) ([]string, error) {
+	index := map[string]int{}
+	for i, name := range names {
+		index[name] = i
+	}
+
+	var result []string
+	state := map[string]int{} // 0 = unvisited, 1 = visiting, 2 = done
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("circular dependency on %s", name)
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		deps := append([]string{}, GetDependencies(name)...)
+		sort.Slice(deps, func(i, j int) bool {
+			return index[deps[i]] < index[deps[j]]
+		})
+		for _, dep := range deps {
+			if _, ok := index[dep]; !ok {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		result = append(result, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// GetDependents returns the services among names which depend on service.
+func GetDependents(service string, names []string) []string {
+	var result []string
+	for _, name := range names {
+		for _, dep := range GetDependencies(name) {
+			if dep == service {
+				result = append(result, name)
+				break
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func init() {
+	// a -> b -> c, d has no dependencies, x and y depend on each other
+	Register(Factory{Name: "test-a", Dependencies: []string{"test-b"}})
+	Register(Factory{Name: "test-b", Dependencies: []string{"test-c", "test-missing"}})
+	Register(Factory{Name: "test-c"})
+	Register(Factory{Name: "test-d"})
+	Register(Factory{Name: "test-x", Dependencies: []string{"test-y"}})
+	Register(Factory{Name: "test-y", Dependencies: []string{"test-x"}})
+}
+
+func TestSortByDependencies(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  []string
+		err   string
+	}{
+		{
+			names: []string{"test-c", "test-b", "test-a"},
+			want:  []string{"test-c", "test-b", "test-a"},
+		},
+		{
+			names: []string{"test-a", "test-b", "test-c"},
+			want:  []string{"test-c", "test-b", "test-a"},
+		},
+		{
+			names: []string{"test-d", "test-a", "test-c", "test-b"},
+			want:  []string{"test-d", "test-c", "test-b", "test-a"},
+		},
+		{
+			// dependencies which aren't configured are ignored
+			names: []string{"test-a", "test-d"},
+			want:  []string{"test-a", "test-d"},
+		},
+		{
+			// unknown services have no dependencies
+			names: []string{"mm", "test-b", "test-c"},
+			want:  []string{"mm", "test-c", "test-b"},
+		},
+		{
+			names: []string{"test-d", "test-x", "test-y"},
+			err:   "circular dependency on test-x",
+		},
+		{
+			names: nil,
+			want:  nil,
+		},
+	}
+	for _, test := range tests {
+		got, err := SortByDependencies(test.names)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%v: got error %v, want %q", test.names, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %s", test.names, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %v, want %v", test.names, got, test.want)
+		}
+	}
+}
+
+func TestGetDependents(t *testing.T) {
+	names := []string{"test-a", "test-b", "test-c", "test-d"}
+	tests := []struct {
+		service string
+		want    []string
+	}{
+		{service: "test-c", want: []string{"test-b"}},
+		{service: "test-b", want: []string{"test-a"}},
+		{service: "test-a", want: nil},
+		{service: "test-missing", want: []string{"test-b"}},
+	}
+	for _, test := range tests {
+		got := GetDependents(test.service, names)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.service, got, test.want)
+		}
+	}
+}
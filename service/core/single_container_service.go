@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
 	docker "github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/stdcopy"
 	"io"
@@ -53,20 +54,29 @@ func NewSingleContainerService(
 	return s
 }
 
-// GetStatus implements Service interface
-func (t *SingleContainerService) GetStatus(ctx context.Context) string {
+// GetStatus implements Service interface. A running container is reported
+// as ready, services with an RPC interface refine it further.
+func (t *SingleContainerService) GetStatus(ctx context.Context) Status {
 	status, err := t.GetContainerStatus()
 	if err != nil {
 		t.logger.Debugf("Failed to get container status: %s", err)
 		if strings.Contains(err.Error(), "container not found") {
 			if t.IsDisabled() && (t.GetMode() == "" || t.GetMode() == "native") {
-				return "Disabled"
+				return NewStatus(StateDisabled, "Disabled")
 			}
-			return "Container missing"
+			return NewStatus(StateMissing, "Container missing")
 		}
-		return fmt.Sprintf("Error: %s", err)
+		return ErrorStatus(err)
+	}
+	message := fmt.Sprintf("Container %s", status)
+	switch status {
+	case "running":
+		return NewStatus(StateReady, message)
+	case "restarting":
+		return NewStatus(StateStarting, message)
+	default:
+		return NewStatus(StateStopped, message)
 	}
-	return fmt.Sprintf("Container %s", status)
 }
 
 func (t *SingleContainerService) GetContainerStatus() (string, error) {
@@ -85,6 +95,20 @@ func (t *SingleContainerService) GetContainerId() string {
 	return c.ID
 }
 
+func (t *SingleContainerService) GetContainerName() string {
+	return t.containerName
+}
+
+// GetImage returns the image (with tag) of the container, or an empty string
+// if the container does not exist.
+func (t *SingleContainerService) GetImage() string {
+	c, err := t.GetContainer()
+	if err != nil {
+		return ""
+	}
+	return c.Config.Image
+}
+
 func (t *SingleContainerService) Getenv(key string) (string, error) {
 	c, err := t.GetContainer()
 	if err != nil {
@@ -174,31 +198,86 @@ func (t *SingleContainerService) FollowLogs(since string, tail string) (<-chan s
 	return ch, func() { reader.Close() }, nil
 }
 
-func (t *SingleContainerService) FollowLogs2() (<-chan string, func(), error) {
+// FollowLogs2 follows the logs of the container across restarts. since and
+// tail only apply to the first attach; an empty since starts at the current
+// start of the container. After a restart the logs are followed from the new
+// start. Each time the container stops "--- EOF ---" is sent.
+func (t *SingleContainerService) FollowLogs2(since string, tail string) (<-chan string, func(), error) {
 	ch := make(chan string)
-	var running = true
+	done := make(chan struct{})
+	var once sync.Once
 
 	go func() {
-		for running {
-			c := t.WaitContainerRunning()
+		defer close(ch)
+		first := true
+		var lastStartedAt string
+		for {
+			c := t.waitContainerRestarted(lastStartedAt, done)
+			if c == nil {
+				return
+			}
 			startedAt := c.State.StartedAt
-			lines, stop, err := t.FollowLogs(startedAt, "")
+			s, n := startedAt, ""
+			if first {
+				if since != "" {
+					s = since
+				}
+				n = tail
+			}
+
+			lines, stop, err := t.FollowLogs(s, n)
 			if err != nil {
-				t.logger.Error("Failed to follow logs: %s", err)
-				time.Sleep(3 * time.Second)
+				t.logger.Errorf("Failed to follow logs: %s", err)
+				select {
+				case <-done:
+					return
+				case <-time.After(3 * time.Second):
+				}
+				continue
 			}
+			first = false
+			lastStartedAt = startedAt
+
+			stopped := false
 			for line := range lines {
-				if !running {
+				select {
+				case ch <- line:
+				case <-done:
+					stopped = true
+				}
+				if stopped {
 					break
 				}
-				ch <- line
 			}
 			stop()
+			if stopped {
+				// drain so that the reading goroutine of FollowLogs can exit
+				for range lines {
+				}
+				return
+			}
 		}
-		close(ch)
 	}()
 
-	return ch, func() { running = false }, nil
+	return ch, func() { once.Do(func() { close(done) }) }, nil
+}
+
+// waitContainerRestarted waits until the container is running with a start
+// time different from startedAt. It returns nil once done is closed.
+func (t *SingleContainerService) waitContainerRestarted(startedAt string, done <-chan struct{}) *types.ContainerJSON {
+	for {
+		t.mutex.Lock()
+		c := t.container
+		t.mutex.Unlock()
+		if c != nil && c.State.Status == "running" && c.State.StartedAt != startedAt {
+			return c
+		}
+		select {
+		case <-done:
+			return nil
+		case <-time.After(time.Second):
+		}
+	}
 }
 
 func (t *SingleContainerService) Exec1(command []string) (string, error) {
@@ -367,3 +446,65 @@ func (t *SingleContainerService) OnEvent(kind string) {
 		t.setContainer(nil)
 	}
 }
+
+// StartContainer starts the container and refreshes the cached container
+// state through OnEvent without waiting for the Docker event.
+func (t *SingleContainerService) StartContainer(ctx context.Context) error {
+	if err := t.dockerClient.ContainerStart(ctx, t.containerName, types.ContainerStartOptions{}); err != nil {
+		return err
+	}
+	t.OnEvent("start")
+	return nil
+}
+
+func (t *SingleContainerService) StopContainer(ctx context.Context, timeout time.Duration) error {
+	if err := t.dockerClient.ContainerStop(ctx, t.containerName, &timeout); err != nil {
+		return err
+	}
+	t.OnEvent("die")
+	return nil
+}
+
+func (t *SingleContainerService) RestartContainer(ctx context.Context, timeout time.Duration) error {
+	if err := t.dockerClient.ContainerRestart(ctx, t.containerName, &timeout); err != nil {
+		return err
+	}
+	t.OnEvent("start")
+	return nil
+}
+
+// RecreateContainer replaces the container with a new one using the same
+// image, config and networks, which discards its writable layer.
+func (t *SingleContainerService) RecreateContainer(ctx context.Context, timeout time.Duration) error {
+	c, err := t.dockerClient.ContainerInspect(ctx, t.containerName)
+	if err != nil {
+		return err
+	}
+	if err := t.dockerClient.ContainerStop(ctx, c.ID, &timeout); err != nil {
+		return err
+	}
+	if err := t.dockerClient.ContainerRemove(ctx, c.ID, types.ContainerRemoveOptions{}); err != nil {
+		return err
+	}
+	t.OnEvent("destroy")
+
+	networking := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{}}
+	for name, settings := range c.NetworkSettings.Networks {
+		// drop the short ID alias of the old container
+		var aliases []string
+		for _, alias := range settings.Aliases {
+			if !strings.HasPrefix(c.ID, alias) {
+				aliases = append(aliases, alias)
+			}
+		}
+		networking.EndpointsConfig[name] = &network.EndpointSettings{
+			Aliases:   aliases,
+			NetworkID: settings.NetworkID,
+		}
+	}
+	if _, err := t.dockerClient.ContainerCreate(ctx, c.Config, c.HostConfig, networking, nil, t.containerName); err != nil {
+		return err
+	}
+	t.OnEvent("create")
+	return t.StartContainer(ctx)
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/docker/docker/api/types"
+	"strings"
+)
+
+// ContainerStats is a summary of the Docker stats of a container.
+type ContainerStats struct {
+	Timestamp     int64   `json:"timestamp"`
+	CpuPercent    float64 `json:"cpuPercent"`
+	MemoryUsage   uint64  `json:"memoryUsage"`
+	MemoryLimit   uint64  `json:"memoryLimit"`
+	MemoryPercent float64 `json:"memoryPercent"`
+	NetworkRx     uint64  `json:"networkRx"`
+	NetworkTx     uint64  `json:"networkTx"`
+	BlockRead     uint64  `json:"blockRead"`
+	BlockWrite    uint64  `json:"blockWrite"`
+}
+
+// newContainerStats computes the same figures as the "docker stats" command.
+func newContainerStats(s *types.StatsJSON) ContainerStats {
+	result := ContainerStats{
+		Timestamp:   s.Read.Unix(),
+		MemoryLimit: s.MemoryStats.Limit,
+	}
+
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	cpus := float64(s.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		result.CpuPercent = cpuDelta / systemDelta * cpus * 100.0
+	}
+
+	// page cache is not counted as used memory
+	result.MemoryUsage = s.MemoryStats.Usage
+	if cache, ok := s.MemoryStats.Stats["cache"]; ok && cache < result.MemoryUsage {
+		result.MemoryUsage -= cache
+	}
+	if s.MemoryStats.Limit > 0 {
+		result.MemoryPercent = float64(result.MemoryUsage) / float64(s.MemoryStats.Limit) * 100.0
+	}
+
+	for _, n := range s.Networks {
+		result.NetworkRx += n.RxBytes
+		result.NetworkTx += n.TxBytes
+	}
+
+	for _, entry := range s.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			result.BlockRead += entry.Value
+		case "write":
+			result.BlockWrite += entry.Value
+		}
+	}
+
+	return result
+}
+
+func (t *SingleContainerService) GetStats(ctx context.Context) (*ContainerStats, error) {
+	resp, err := t.dockerClient.ContainerStats(ctx, t.containerName, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var s types.StatsJSON
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return nil, err
+	}
+	stats := newContainerStats(&s)
+	return &stats, nil
+}
+
+// StreamStats emits the stats of the container about once per second until
+// ctx is done or the container stops.
+func (t *SingleContainerService) StreamStats(ctx context.Context) (<-chan ContainerStats, error) {
+	resp, err := t.dockerClient.ContainerStats(ctx, t.containerName, true)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan ContainerStats)
+
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var s types.StatsJSON
+			if err := decoder.Decode(&s); err != nil {
+				t.logger.Debugf("Stopped streaming stats: %s", err)
+				return
+			}
+			select {
+			case ch <- newContainerStats(&s):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+type State string
+
+const (
+	StateDisabled State = "disabled"
+	StateMissing  State = "missing"
+	StateStopped  State = "stopped"
+	StateStarting State = "starting"
+	StateSyncing  State = "syncing"
+	StateLocked   State = "locked"
+	StateWaiting  State = "waiting"
+	StateReady    State = "ready"
+	StateError    State = "error"
+)
+
+type Progress struct {
+	Current int64   `json:"current"`
+	Total   int64   `json:"total"`
+	Percent float64 `json:"percent"`
+}
+
+// Status is the machine-readable status of a service. Message is the
+// human-readable text which is also served by /api/v1/status.
+type Status struct {
+	State     State     `json:"state"`
+	Progress  *Progress `json:"progress,omitempty"`
+	Message   string    `json:"message"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp int64     `json:"timestamp"`
+}
+
+func NewStatus(state State, message string) Status {
+	return Status{
+		State:     state,
+		Message:   message,
+		Timestamp: time.Now().Unix(),
+	}
+}
+
+func ErrorStatus(err error) Status {
+	s := NewStatus(StateError, fmt.Sprintf("Error: %s", err))
+	s.Error = err.Error()
+	return s
+}
+
+func NewProgress(current int64, total int64) *Progress {
+	p := &Progress{Current: current, Total: total}
+	if total > 0 {
+		p.Percent = float64(current) / float64(total) * 100.0
+	}
+	return p
+}
+
+// SyncingStatus returns a syncing status with the "Syncing 42.13% (123/456)"
+// message.
+func SyncingStatus(current int64, total int64) Status {
+	p := NewProgress(current, total)
+	s := NewStatus(StateSyncing, fmt.Sprintf("Syncing %.2f%% (%d/%d)", p.Percent, current, total))
+	s.Progress = p
+	return s
+}
+
+func (t Status) IsReady() bool {
+	return t.State == StateReady
+}
+
+func (t Status) String() string {
+	return t.Message
+}
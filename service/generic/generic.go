@@ -0,0 +1,38 @@
+package generic
+
+import (
+	"context"
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	docker "github.com/docker/docker/client"
+)
+
+// Service is a container described by a "docker" entry in config.json. It
+// has no RPC interface, so its status is the container status.
+type Service struct {
+	*core.SingleContainerService
+}
+
+func New(
+	name string,
+	services map[string]core.Service,
+	containerName string,
+	dockerClient *docker.Client,
+) *Service {
+	return &Service{
+		SingleContainerService: core.NewSingleContainerService(name, services, containerName, dockerClient),
+	}
+}
+
+func (t *Service) GetStatus(ctx context.Context) core.Status {
+	status := t.SingleContainerService.GetStatus(ctx)
+	if !status.IsReady() {
+		return status
+	}
+
+	// container is running
+	return core.NewStatus(core.StateReady, "Ready")
+}
+
+func (t *Service) Close() error {
+	return nil
+}
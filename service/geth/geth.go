@@ -3,7 +3,6 @@ package geth
 import (
 	"context"
 	"errors"
-	"fmt"
 	"github.com/ExchangeUnion/xud-docker-api/config"
 	"github.com/ExchangeUnion/xud-docker-api/service/connext"
 	"github.com/ExchangeUnion/xud-docker-api/service/core"
@@ -30,6 +29,40 @@ const (
 	Unknown  Mode = "unknown"
 )
 
+var (
+	defaultLightProviders = map[string][]string{
+		"testnet": {
+			"http://eth.kilrau.com:52041",
+			"http://michael1011.at:8546",
+			"http://gethxudxv2k4pv5t5a5lswq2hcv3icmj3uwg7m2n2vuykiyv77legiad.onion:8546",
+		},
+		"mainnet": {
+			"http://eth.kilrau.com:41007",
+			"http://michael1011.at:8545",
+			"http://gethxudxv2k4pv5t5a5lswq2hcv3icmj3uwg7m2n2vuykiyv77legiad.onion:8545",
+		},
+	}
+)
+
+func init() {
+	core.Register(core.Factory{
+		Name:        "geth",
+		DisplayName: "Geth",
+		Rpc:         config.JsonRpc,
+		Commands: []core.ConsoleCommand{
+			{Name: "geth", Description: "geth cli", Command: "geth"},
+		},
+		ParseLog: ParseLog,
+		New: func(ctx core.FactoryContext, c config.ServiceConfig) (core.Service, error) {
+			rpc, err := c.JsonRpcConfig()
+			if err != nil {
+				return nil, err
+			}
+			return New(c.Name, ctx.Services, ctx.ContainerName, ctx.DockerClient, "connext", defaultLightProviders[ctx.Network], *rpc), nil
+		},
+	})
+}
+
 func New(
 	name string,
 	services map[string]core.Service,
@@ -37,7 +70,7 @@ func New(
 	dockerClient *docker.Client,
 	l2ServiceName string,
 	lightProviders []string,
-	rpcConfig config.RpcConfig,
+	rpcConfig config.JsonRpcConfig,
 ) *Service {
 	return &Service{
 		SingleContainerService: core.NewSingleContainerService(name, services, containerName, dockerClient),
@@ -110,46 +143,46 @@ func (t *Service) getMode() (Mode, error) {
 	}
 }
 
-func (t *Service) getExternalStatus() string {
+func (t *Service) getExternalStatus() core.Status {
 	provider, err := t.getProvider()
 	if err != nil {
-		return "No provider"
+		return core.NewStatus(core.StateError, "No provider")
 	}
 	if t.checkEthRpc(provider) {
-		return "Ready (connected to external)"
+		return core.NewStatus(core.StateReady, "Ready (connected to external)")
 	} else {
-		return "Unavailable (connection to external failed)"
+		return core.NewStatus(core.StateError, "Unavailable (connection to external failed)")
 	}
 }
 
-func (t *Service) getInfuraStatus() string {
+func (t *Service) getInfuraStatus() core.Status {
 	provider, err := t.getProvider()
 	if err != nil {
-		return "No provider"
+		return core.NewStatus(core.StateError, "No provider")
 	}
 	if t.checkEthRpc(provider) {
-		return "Ready (connected to Infura)"
+		return core.NewStatus(core.StateReady, "Ready (connected to Infura)")
 	} else {
-		return "Unavailable (connection to Infura failed)"
+		return core.NewStatus(core.StateError, "Unavailable (connection to Infura failed)")
 	}
 }
 
-func (t *Service) getLightStatus() string {
+func (t *Service) getLightStatus() core.Status {
 	provider, err := t.getProvider()
 	if err != nil {
-		return "No provider"
+		return core.NewStatus(core.StateError, "No provider")
 	}
 	if t.checkEthRpc(provider) {
-		return "Ready (light mode)"
+		return core.NewStatus(core.StateReady, "Ready (light mode)")
 	} else {
-		return "Unavailable (light mode failed)"
+		return core.NewStatus(core.StateError, "Unavailable (light mode failed)")
 	}
 }
 
-func (t *Service) GetStatus(ctx context.Context) string {
+func (t *Service) GetStatus(ctx context.Context) core.Status {
 	mode, err := t.getMode()
 	if err != nil {
-		return fmt.Sprintf("Error: %s", err)
+		return core.ErrorStatus(err)
 	}
 
 	if mode == External {
@@ -161,10 +194,7 @@ func (t *Service) GetStatus(ctx context.Context) string {
 	}
 
 	status := t.SingleContainerService.GetStatus(ctx)
-	if status == "Disabled" {
-		return status
-	}
-	if status != "Container running" {
+	if !status.IsReady() {
 		return status
 	}
 
@@ -172,22 +202,19 @@ func (t *Service) GetStatus(ctx context.Context) string {
 
 	syncing, err := t.EthSyncing()
 	if err != nil {
-		return "Waiting for geth to come up..."
+		return core.NewStatus(core.StateStarting, "Waiting for geth to come up...")
 	}
 	if syncing != nil {
-		current := syncing.CurrentBlock
-		total := syncing.HighestBlock
-		p := float32(current) / float32(total) * 100.0
-		return fmt.Sprintf("Syncing %.2f%% (%d/%d)", p, current, total)
+		return core.SyncingStatus(int64(syncing.CurrentBlock), int64(syncing.HighestBlock))
 	} else {
 		blockNumber, err := t.EthBlockNumber()
 		if err != nil {
-			return "Waiting for geth to come up..."
+			return core.NewStatus(core.StateStarting, "Waiting for geth to come up...")
 		}
 		if blockNumber == 0 {
-			return "Waiting for sync"
+			return core.NewStatus(core.StateWaiting, "Waiting for sync")
 		} else {
-			return "Ready"
+			return core.NewStatus(core.StateReady, "Ready")
 		}
 	}
 }
@@ -0,0 +1,30 @@
+package geth
+
+import (
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// INFO [01-05|10:00:00.123] Imported new chain segment  blocks=1
+var logPattern = regexp.MustCompile(`^(TRACE|DEBUG|INFO|WARN|ERROR|CRIT)\s*\[(\d{2}-\d{2}\|\d{2}:\d{2}:\d{2}(?:\.\d+)?)\] (.*)$`)
+
+func ParseLog(line string) (*core.LogRecord, bool) {
+	m := logPattern.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	// geth leaves out the year, so take the latest one which isn't in the
+	// future
+	year := time.Now().UTC().Year()
+	ts := core.ParseTime("2006-01-02|15:04:05", strconv.Itoa(year)+"-"+m[2])
+	if ts > time.Now().Unix() {
+		ts = core.ParseTime("2006-01-02|15:04:05", strconv.Itoa(year-1)+"-"+m[2])
+	}
+	return &core.LogRecord{
+		Timestamp: ts,
+		Level:     core.NormalizeLevel(m[1]),
+		Message:   m[3],
+	}, true
+}
@@ -0,0 +1,72 @@
+package geth
+
+import (
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"testing"
+	"time"
+)
+
+func TestParseLog(t *testing.T) {
+	tests := []struct {
+		line    string
+		ok      bool
+		level   string
+		message string
+	}{
+		{
+			line:    "INFO [01-05|10:00:00.123] Imported new chain segment  blocks=1",
+			ok:      true,
+			level:   core.LevelInfo,
+			message: "Imported new chain segment  blocks=1",
+		},
+		{
+			line:    "WARN [12-31|23:59:59.000] Synchronisation failed, dropping peer",
+			ok:      true,
+			level:   core.LevelWarn,
+			message: "Synchronisation failed, dropping peer",
+		},
+		{
+			line:    "CRIT [01-05|10:00:00] Failed to start",
+			ok:      true,
+			level:   core.LevelFatal,
+			message: "Failed to start",
+		},
+		{line: "Fatal: Failed to write genesis block"},
+	}
+	for _, test := range tests {
+		got, ok := ParseLog(test.line)
+		if ok != test.ok {
+			t.Errorf("%q: ok = %v, want %v", test.line, ok, test.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if got.Level != test.level || got.Message != test.message {
+			t.Errorf("%q: got %+v, want level %q and message %q", test.line, *got, test.level, test.message)
+		}
+	}
+}
+
+func TestParseLogYear(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []time.Time{
+		now.Add(-time.Hour).Truncate(time.Second),
+		// a day from now must be from last year
+		now.Add(24*time.Hour).AddDate(-1, 0, 0).Truncate(time.Second),
+	}
+	for _, want := range tests {
+		// February 29 doesn't exist in every year
+		if want.Month() == time.February && want.Day() == 29 {
+			continue
+		}
+		line := "INFO [" + want.Format("01-02|15:04:05") + "] message"
+		got, ok := ParseLog(line)
+		if !ok {
+			t.Fatalf("%q: got no record", line)
+		}
+		if got.Timestamp != want.Unix() {
+			t.Errorf("%q: got %s, want %s", line, time.Unix(got.Timestamp, 0).UTC(), want)
+		}
+	}
+}
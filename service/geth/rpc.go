@@ -1,29 +1,32 @@
 package geth
 
 import (
-	"fmt"
 	"github.com/ExchangeUnion/xud-docker-api/config"
-	"github.com/ybbus/jsonrpc"
+	"github.com/ExchangeUnion/xud-docker-api/rpc"
 	"strconv"
 	"strings"
 )
 
 type RpcClient struct {
-	client jsonrpc.RPCClient
+	conn *rpc.JsonrpcConn
 }
 
-func NewRpcClient(config config.RpcConfig) *RpcClient {
-	host := config["host"].(string)
-	port := uint16(config["port"].(float64))
-
-	addr := fmt.Sprintf("http://%s:%d", host, port)
-	client := jsonrpc.NewClientWithOpts(addr, &jsonrpc.RPCClientOpts{})
-
+func NewRpcClient(config config.JsonRpcConfig) *RpcClient {
 	return &RpcClient{
-		client: client,
+		conn: rpc.NewJsonrpcConn(config, 0),
 	}
 }
 
+// Reconfigure applies a changed rpc block of config.json.
+func (t *RpcClient) Reconfigure(c config.ServiceConfig) error {
+	rpcConfig, err := c.JsonRpcConfig()
+	if err != nil {
+		return err
+	}
+	t.conn.Update(*rpcConfig)
+	return nil
+}
+
 type Syncing struct {
 	CurrentBlock  int64
 	HighestBlock  int64
@@ -42,7 +45,7 @@ func parseHex(value string) (int64, error) {
 }
 
 func (t *RpcClient) EthSyncing() (*Syncing, error) {
-	result, err := t.client.Call("eth_syncing")
+	result, err := t.conn.Call("eth_syncing")
 	if err != nil {
 		return nil, err
 	}
@@ -92,7 +95,7 @@ func (t *RpcClient) EthSyncing() (*Syncing, error) {
 }
 
 func (t *RpcClient) EthBlockNumber() (int64, error) {
-	result, err := t.client.Call("eth_blockNumber")
+	result, err := t.conn.Call("eth_blockNumber")
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	historyFile      = "history.db"
+	historyRetention = 90 * 24 * time.Hour
+
+	defaultHistorySince  = "24h"
+	defaultUptimeWindows = "1h,24h,7d"
+)
+
+// StatusTransition records a service changing from one state to another.
+// Reason is the status message (and error) of the new state.
+type StatusTransition struct {
+	Timestamp int64      `json:"timestamp"`
+	Old       core.State `json:"old"`
+	New       core.State `json:"new"`
+	Reason    string     `json:"reason"`
+}
+
+type ServiceUptime struct {
+	Service string             `json:"service"`
+	Uptime  map[string]float64 `json:"uptime"`
+}
+
+// StatusHistory persists status transitions in a bbolt database with one
+// bucket per service. Keys are big-endian UnixNano timestamps so that a
+// cursor walks the transitions in chronological order.
+type StatusHistory struct {
+	db     *bolt.DB
+	logger *logrus.Entry
+}
+
+func NewStatusHistory(dataDir string, logger *logrus.Entry) (*StatusHistory, error) {
+	db, err := bolt.Open(filepath.Join(dataDir, historyFile), 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open status history: %s", err)
+	}
+	return &StatusHistory{
+		db:     db,
+		logger: logger,
+	}, nil
+}
+
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
+
+// Record stores a transition if the state of service differs from the last
+// recorded one. Transitions older than historyRetention are pruned.
+func (t *StatusHistory) Record(service string, status core.Status) error {
+	return t.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(service))
+		if err != nil {
+			return err
+		}
+
+		var old core.State
+		if _, v := b.Cursor().Last(); v != nil {
+			var last StatusTransition
+			if err := json.Unmarshal(v, &last); err != nil {
+				return err
+			}
+			if last.New == status.State {
+				return nil
+			}
+			old = last.New
+		}
+
+		now := time.Now()
+		reason := status.Message
+		if status.Error != "" && !strings.Contains(reason, status.Error) {
+			reason = reason + ": " + status.Error
+		}
+		j, err := json.Marshal(StatusTransition{
+			Timestamp: now.Unix(),
+			Old:       old,
+			New:       status.State,
+			Reason:    reason,
+		})
+		if err != nil {
+			return err
+		}
+		if err := b.Put(timeKey(now), j); err != nil {
+			return err
+		}
+
+		return pruneBefore(b, timeKey(now.Add(-historyRetention)))
+	})
+}
+
+// pruneBefore deletes the keys of b which sort before cutoff. The keys are
+// collected first because deleting with a cursor moves it to the next key,
+// so calling Next afterwards would skip every other one.
+func pruneBefore(b *bolt.Bucket, cutoff []byte) error {
+	var keys [][]byte
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil && string(k) < string(cutoff); k, _ = c.Next() {
+		keys = append(keys, append([]byte{}, k...))
+	}
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the transitions of service since the given time. The last
+// transition before since is included as well because it tells the state
+// the service was in at that time.
+func (t *StatusHistory) Get(service string, since time.Time) ([]StatusTransition, error) {
+	result := []StatusTransition{}
+	err := t.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(service))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		k, v := c.Seek(timeKey(since))
+		if k == nil {
+			k, v = c.Last()
+		} else if string(k) > string(timeKey(since)) {
+			if pk, pv := c.Prev(); pk != nil {
+				k, v = pk, pv
+			} else {
+				k, v = c.First()
+			}
+		}
+		for ; k != nil; k, v = c.Next() {
+			var item StatusTransition
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			result = append(result, item)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// Uptime returns the percentage of the window which service spent in the
+// ready state. Time before the first recorded transition is not counted.
+func (t *StatusHistory) Uptime(service string, window time.Duration) (float64, error) {
+	now := time.Now()
+	start := now.Add(-window)
+	transitions, err := t.Get(service, start)
+	if err != nil {
+		return 0, err
+	}
+	if len(transitions) == 0 {
+		return 0, nil
+	}
+
+	var total, ready time.Duration
+	for i, item := range transitions {
+		from := time.Unix(item.Timestamp, 0)
+		if from.Before(start) {
+			from = start
+		}
+		to := now
+		if i+1 < len(transitions) {
+			to = time.Unix(transitions[i+1].Timestamp, 0)
+		}
+		if !to.After(from) {
+			continue
+		}
+		total += to.Sub(from)
+		if item.New == core.StateReady {
+			ready += to.Sub(from)
+		}
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	return float64(ready) / float64(total) * 100.0, nil
+}
+
+func (t *StatusHistory) Close() error {
+	return t.db.Close()
+}
+
+// parseWindow is time.ParseDuration with support for days, e.g. "7d".
+func parseWindow(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil || days <= 0 {
+			return 0, fmt.Errorf("invalid window: %s", value)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid window: %s", value)
+	}
+	return d, nil
+}
+
+// parseSince accepts a Unix timestamp or a window relative to now.
+func parseSince(value string) (time.Time, error) {
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(ts, 0), nil
+	}
+	d, err := parseWindow(value)
+	if err != nil {
+		return time.Time{}, errors.New("invalid since: " + value)
+	}
+	return time.Now().Add(-d), nil
+}
+
+// recordHistory stores the transitions emitted by the StatusPoller.
+func (t *Manager) recordHistory() {
+	updates, _ := t.poller.Subscribe()
+	for status := range updates {
+		if err := t.history.Record(status.Service, status.Status); err != nil {
+			t.logger.Errorf("Failed to record status of %s: %s", status.Service, err)
+		}
+	}
+}
+
+func (t *Manager) configureHistoryRouter(r *gin.RouterGroup) {
+	r.GET("/v1/status/:service/history", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		service := c.Param("service")
+		if _, err := t.GetService(service); err != nil {
+			utils.JsonError(c, err.Error(), http.StatusNotFound)
+			return
+		}
+		since, err := parseSince(c.DefaultQuery("since", defaultHistorySince))
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		transitions, err := t.history.Get(service, since)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, transitions)
+	})
+
+	r.GET("/v1/uptime", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		windows := strings.Split(c.DefaultQuery("windows", defaultUptimeWindows), ",")
+		durations := map[string]time.Duration{}
+		for _, w := range windows {
+			d, err := parseWindow(w)
+			if err != nil {
+				utils.JsonError(c, err.Error(), http.StatusBadRequest)
+				return
+			}
+			durations[w] = d
+		}
+
+		var result []ServiceUptime
+		for _, s := range t.getServices() {
+			entry := ServiceUptime{Service: s.GetName(), Uptime: map[string]float64{}}
+			for w, d := range durations {
+				uptime, err := t.history.Uptime(s.GetName(), d)
+				if err != nil {
+					utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				entry.Uptime[w] = uptime
+			}
+			result = append(result, entry)
+		}
+		c.JSON(http.StatusOK, result)
+	})
+}
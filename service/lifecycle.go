@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultStopTimeout = 30 * time.Second
+	lifecycleTimeout   = 5 * time.Minute
+)
+
+type LifecycleAction string
+
+const (
+	ActionStart    LifecycleAction = "start"
+	ActionStop     LifecycleAction = "stop"
+	ActionRestart  LifecycleAction = "restart"
+	ActionRecreate LifecycleAction = "recreate"
+)
+
+// LifecycleEvent reports the progress of a lifecycle operation. Stage is one
+// of "warning", "started", "done" and "error".
+type LifecycleEvent struct {
+	Timestamp int64           `json:"timestamp"`
+	Service   string          `json:"service"`
+	Action    LifecycleAction `json:"action"`
+	Stage     string          `json:"stage"`
+	Message   string          `json:"message,omitempty"`
+}
+
+type LifecycleOptions struct {
+	// Cascade also stops the services depending on the target (or starts
+	// the ones it depends on) in dependency order instead of only warning
+	Cascade bool
+	Timeout time.Duration
+}
+
+func errNoContainer(name string) error {
+	return fmt.Errorf("service %s has no container", name)
+}
+
+func (t *Manager) getServiceNames() []string {
+	var names []string
+	for _, s := range t.getServices() {
+		names = append(names, s.GetName())
+	}
+	return names
+}
+
+// getTransitiveDependents returns all services which directly or indirectly
+// depend on service, ordered so that every service comes after the services
+// it depends on.
+func (t *Manager) getTransitiveDependents(service string) []string {
+	names := t.getServiceNames()
+	seen := map[string]bool{service: true}
+	queue := []string{service}
+	var result []string
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, dependent := range core.GetDependents(name, names) {
+			if !seen[dependent] {
+				seen[dependent] = true
+				result = append(result, dependent)
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	sorted, err := core.SortByDependencies(result)
+	if err != nil {
+		return result
+	}
+	return sorted
+}
+
+// getTransitiveDependencies returns all configured services which service
+// directly or indirectly depends on, in dependency order.
+func (t *Manager) getTransitiveDependencies(service string) []string {
+	configured := map[string]bool{}
+	for _, name := range t.getServiceNames() {
+		configured[name] = true
+	}
+	seen := map[string]bool{service: true}
+	queue := []string{service}
+	var result []string
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, dep := range core.GetDependencies(name) {
+			if configured[dep] && !seen[dep] {
+				seen[dep] = true
+				result = append(result, dep)
+				queue = append(queue, dep)
+			}
+		}
+	}
+	sorted, err := core.SortByDependencies(result)
+	if err != nil {
+		return result
+	}
+	return sorted
+}
+
+func (t *Manager) isRunning(name string) bool {
+	s, err := t.GetService(name)
+	if err != nil || s.IsDisabled() {
+		return false
+	}
+	c, ok := s.(containerService)
+	if !ok {
+		return false
+	}
+	container, err := c.GetContainer()
+	return err == nil && container.State.Running
+}
+
+func (t *Manager) getController(name string) (core.ContainerController, error) {
+	if name == "proxy" {
+		return nil, errors.New("the proxy cannot control its own container")
+	}
+	s, err := t.GetService(name)
+	if err != nil {
+		return nil, err
+	}
+	if s.IsDisabled() {
+		return nil, fmt.Errorf("service %s is disabled", name)
+	}
+	c, ok := s.(core.ContainerController)
+	if !ok {
+		return nil, errNoContainer(name)
+	}
+	return c, nil
+}
+
+func (t *Manager) runAction(ctx context.Context, name string, action LifecycleAction, timeout time.Duration, emit func(LifecycleEvent)) error {
+	c, err := t.getController(name)
+	if err != nil {
+		emit(LifecycleEvent{Service: name, Action: action, Stage: "error", Message: err.Error()})
+		return err
+	}
+	emit(LifecycleEvent{Service: name, Action: action, Stage: "started"})
+	switch action {
+	case ActionStart:
+		err = c.StartContainer(ctx)
+	case ActionStop:
+		err = c.StopContainer(ctx, timeout)
+	case ActionRestart:
+		err = c.RestartContainer(ctx, timeout)
+	case ActionRecreate:
+		err = c.RecreateContainer(ctx, timeout)
+	default:
+		err = fmt.Errorf("unsupported action: %s", action)
+	}
+	if err != nil {
+		emit(LifecycleEvent{Service: name, Action: action, Stage: "error", Message: err.Error()})
+		return err
+	}
+	emit(LifecycleEvent{Service: name, Action: action, Stage: "done"})
+	return nil
+}
+
+// RunLifecycle performs action on service. Without opts.Cascade, affected
+// services are only reported as warnings: running dependents for stop,
+// restart and recreate, stopped dependencies for start. With it, dependents
+// are stopped first (and started again afterwards for restart and recreate)
+// and dependencies are started first.
+func (t *Manager) RunLifecycle(ctx context.Context, service string, action LifecycleAction, opts LifecycleOptions, emit func(LifecycleEvent)) error {
+	if _, err := t.getController(service); err != nil {
+		return err
+	}
+
+	var before []LifecycleEvent
+	var stopFirst, startFirst, startAfter []string
+
+	if action == ActionStart {
+		for _, dep := range t.getTransitiveDependencies(service) {
+			if t.isRunning(dep) {
+				continue
+			}
+			if opts.Cascade {
+				startFirst = append(startFirst, dep)
+			} else {
+				before = append(before, LifecycleEvent{Service: service, Action: action, Stage: "warning", Message: fmt.Sprintf("%s depends on %s which is not running", service, dep)})
+			}
+		}
+	} else {
+		var running []string
+		for _, dep := range t.getTransitiveDependents(service) {
+			if t.isRunning(dep) {
+				running = append(running, dep)
+			}
+		}
+		if opts.Cascade {
+			for i := len(running) - 1; i >= 0; i-- {
+				stopFirst = append(stopFirst, running[i])
+			}
+			if action != ActionStop {
+				startAfter = running
+			}
+		} else if len(running) > 0 {
+			before = append(before, LifecycleEvent{Service: service, Action: action, Stage: "warning", Message: fmt.Sprintf("running services depending on %s: %s", service, strings.Join(running, ", "))})
+		}
+	}
+
+	for _, e := range before {
+		emit(e)
+	}
+	for _, name := range startFirst {
+		if err := t.runAction(ctx, name, ActionStart, opts.Timeout, emit); err != nil {
+			return err
+		}
+	}
+	for _, name := range stopFirst {
+		if err := t.runAction(ctx, name, ActionStop, opts.Timeout, emit); err != nil {
+			return err
+		}
+	}
+	if err := t.runAction(ctx, service, action, opts.Timeout, emit); err != nil {
+		return err
+	}
+	for _, name := range startAfter {
+		if err := t.runAction(ctx, name, ActionStart, opts.Timeout, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Manager) configureLifecycleRouter(r *gin.RouterGroup) {
+	for _, a := range []LifecycleAction{ActionStart, ActionStop, ActionRestart, ActionRecreate} {
+		action := a
+		r.POST("/v1/services/:service/"+string(action), auth.Require(auth.ScopeAdmin), func(c *gin.Context) {
+			service := c.Param("service")
+
+			opts := LifecycleOptions{Timeout: defaultStopTimeout}
+			opts.Cascade, _ = strconv.ParseBool(c.DefaultQuery("cascade", "false"))
+			if value := c.Query("timeout"); value != "" {
+				seconds, err := strconv.Atoi(value)
+				if err != nil || seconds < 0 {
+					utils.JsonError(c, "invalid timeout: "+value, http.StatusBadRequest)
+					return
+				}
+				opts.Timeout = time.Duration(seconds) * time.Second
+			}
+
+			if _, err := t.getController(service); err != nil {
+				utils.JsonError(c, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			// the operation is not bound to the request so that a
+			// disconnecting client doesn't leave services half-stopped
+			ctx, cancel := context.WithTimeout(context.Background(), lifecycleTimeout)
+			defer cancel()
+
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(http.StatusOK)
+			emit := func(e LifecycleEvent) {
+				e.Timestamp = time.Now().Unix()
+				t.logger.Infof("[%s %s] %s %s", e.Action, e.Service, e.Stage, e.Message)
+				j, _ := json.Marshal(e)
+				c.Writer.Write(j)
+				c.Writer.Write([]byte("\n"))
+				c.Writer.Flush()
+			}
+			_ = t.RunLifecycle(ctx, service, action, opts, emit)
+		})
+	}
+}
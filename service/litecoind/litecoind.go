@@ -11,13 +11,32 @@ type Service struct {
 	*bitcoind.Service
 }
 
+func init() {
+	core.Register(core.Factory{
+		Name:        "litecoind",
+		DisplayName: "Litecoind",
+		Rpc:         config.JsonRpc,
+		Commands: []core.ConsoleCommand{
+			{Name: "litecoin-cli", Description: "litecoind cli", Command: "litecoin-cli -rpcuser=xu -rpcpassword=xu $([[ $NETWORK == testnet ]] && echo -testnet)"},
+		},
+		ParseLog: bitcoind.ParseLog,
+		New: func(ctx core.FactoryContext, c config.ServiceConfig) (core.Service, error) {
+			rpc, err := c.JsonRpcConfig()
+			if err != nil {
+				return nil, err
+			}
+			return New(c.Name, ctx.Services, ctx.ContainerName, ctx.DockerClient, "lndltc", *rpc), nil
+		},
+	})
+}
+
 func New(
 	name string,
 	services map[string]core.Service,
 	containerName string,
 	dockerClient *docker.Client,
 	l2ServiceName string,
-	rpcConfig config.RpcConfig,
+	rpcConfig config.JsonRpcConfig,
 ) *Service {
 	return &Service{
 		bitcoind.New(name, services, containerName, dockerClient, l2ServiceName, rpcConfig),
@@ -3,6 +3,7 @@ package lnd
 import (
 	"context"
 	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
 	"github.com/ExchangeUnion/xud-docker-api/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/protobuf/jsonpb"
@@ -11,7 +12,7 @@ import (
 )
 
 func (t *Service) ConfigureRouter(r *gin.RouterGroup) {
-	r.GET(fmt.Sprintf("/v1/%s/getinfo", t.GetName()), func(c *gin.Context) {
+	r.GET(fmt.Sprintf("/v1/%s/getinfo", t.GetName()), auth.Require(auth.ScopeRead), func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 3 * time.Second)
 		resp, err := t.GetInfo(ctx)
 		cancel()
@@ -28,13 +28,44 @@ func (t *Service) GetBackendNode() (string, error) {
 	return values[0], err
 }
 
+func init() {
+	for _, item := range []struct {
+		name        string
+		displayName string
+		chain       string
+		backend     string
+	}{
+		{"lndbtc", "LND (Bitcoin)", "bitcoin", "bitcoind"},
+		{"lndltc", "LND (Litecoin)", "litecoin", "litecoind"},
+	} {
+		chain := item.chain
+		core.Register(core.Factory{
+			Name:         item.name,
+			DisplayName:  item.displayName,
+			Rpc:          config.Grpc,
+			Dependencies: []string{item.backend},
+			Commands: []core.ConsoleCommand{
+				{Name: item.name + "-lncli", Description: "lnd cli", Command: "lncli -n ${NETWORK} -c " + chain},
+			},
+			ParseLog: ParseLog,
+			New: func(ctx core.FactoryContext, c config.ServiceConfig) (core.Service, error) {
+				rpc, err := c.GrpcConfig()
+				if err != nil {
+					return nil, err
+				}
+				return New(c.Name, ctx.Services, ctx.ContainerName, ctx.DockerClient, chain, *rpc), nil
+			},
+		})
+	}
+}
+
 func New(
 	name string,
 	services map[string]core.Service,
 	containerName string,
 	dockerClient *docker.Client,
 	chain string,
-	rpcConfig config.RpcConfig,
+	rpcConfig config.GrpcConfig,
 ) *Service {
 
 	base := core.NewSingleContainerService(name, services, containerName, dockerClient)
@@ -119,7 +150,7 @@ func (t *Service) Neutrino() bool {
 	return true
 }
 
-func syncingText(current int64, total int64) string {
+func syncingStatus(current int64, total int64) core.Status {
 	if total < current {
 		total = current
 	}
@@ -130,17 +161,16 @@ func syncingText(current int64, total int64) string {
 		p = 0
 	}
 	if total == current && total > 0 {
-		return "Synced 100%. Waiting for wallet creation."
+		return core.NewStatus(core.StateWaiting, "Synced 100%. Waiting for wallet creation.")
 	}
-	return fmt.Sprintf("Syncing %.2f%% (%d/%d)", p, current, total)
+	status := core.NewStatus(core.StateSyncing, fmt.Sprintf("Syncing %.2f%% (%d/%d)", p, current, total))
+	status.Progress = core.NewProgress(current, total)
+	return status
 }
 
-func (t *Service) GetStatus(ctx context.Context) string {
+func (t *Service) GetStatus(ctx context.Context) core.Status {
 	status := t.SingleContainerService.GetStatus(ctx)
-	if status == "Disabled" {
-		return status
-	}
-	if status != "Container running" {
+	if !status.IsReady() {
 		return status
 	}
 
@@ -149,7 +179,7 @@ func (t *Service) GetStatus(ctx context.Context) string {
 	info, err := t.GetInfo(ctx)
 	if err != nil {
 		if strings.Contains(err.Error(), "Wallet is encrypted") {
-			return "Wallet locked. Unlock with lncli unlock."
+			return core.NewStatus(core.StateLocked, "Wallet locked. Unlock with lncli unlock.")
 		} else if strings.Contains(err.Error(), "no such file or directory") {
 			if t.Neutrino() {
 				return t.logWatcher.GetNeutrinoStatus()
@@ -163,7 +193,7 @@ func (t *Service) GetStatus(ctx context.Context) string {
 				return t.logWatcher.GetNeutrinoStatus()
 			}
 		}
-		return fmt.Sprintf("Error: %s", err)
+		return core.ErrorStatus(err)
 	}
 
 	syncedToChain := info.SyncedToChain
@@ -174,15 +204,15 @@ func (t *Service) GetStatus(ctx context.Context) string {
 
 	if err == nil && current > 0 {
 		if total <= current {
-			return "Ready"
+			return core.NewStatus(core.StateReady, "Ready")
 		} else {
-			return syncingText(int64(current), int64(total))
+			return syncingStatus(int64(current), int64(total))
 		}
 	} else {
 		if syncedToChain {
-			return "Ready"
+			return core.NewStatus(core.StateReady, "Ready")
 		} else {
-			return "Syncing"
+			return core.NewStatus(core.StateSyncing, "Syncing")
 		}
 	}
 }
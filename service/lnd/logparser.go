@@ -0,0 +1,22 @@
+package lnd
+
+import (
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"regexp"
+)
+
+// 2021-01-05 10:00:00.123 [ERR] HSWC: message
+var logPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d+) \[(\w+)\] (\w+): (.*)$`)
+
+func ParseLog(line string) (*core.LogRecord, bool) {
+	m := logPattern.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	return &core.LogRecord{
+		Timestamp: core.ParseTime("2006-01-02 15:04:05", m[1]),
+		Level:     core.NormalizeLevel(m[2]),
+		Subsystem: m[3],
+		Message:   m[4],
+	}, true
+}
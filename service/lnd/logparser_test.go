@@ -0,0 +1,39 @@
+package lnd
+
+import (
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"testing"
+)
+
+func TestParseLog(t *testing.T) {
+	tests := []struct {
+		line string
+		want *core.LogRecord
+	}{
+		{
+			line: "2021-01-05 10:00:00.123 [ERR] HSWC: link failed",
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelError, Subsystem: "HSWC", Message: "link failed"},
+		},
+		{
+			line: "2021-01-05 10:00:00.123 [INF] LNWL: Opened wallet",
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelInfo, Subsystem: "LNWL", Message: "Opened wallet"},
+		},
+		{line: "goroutine 1 [running]:"},
+	}
+	for _, test := range tests {
+		got, ok := ParseLog(test.line)
+		if test.want == nil {
+			if ok {
+				t.Errorf("%q: got %+v, want no record", test.line, *got)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%q: got no record, want %+v", test.line, *test.want)
+			continue
+		}
+		if *got != *test.want {
+			t.Errorf("%q: got %+v, want %+v", test.line, *got, *test.want)
+		}
+	}
+}
@@ -76,10 +76,10 @@ func NewLogWatcher(containerName string, service *core.SingleContainerService) *
 
 func (t *LogWatcher) getLogs() <-chan string {
 	for {
-		lines, stop, err := t.service.FollowLogs2()
+		lines, stop, err := t.service.FollowLogs2("", "")
 		t.stop = stop
 		if err != nil {
-			t.logger.Error("Failed to follow logs: %s", err)
+			t.logger.Errorf("Failed to follow logs: %s", err)
 			time.Sleep(3 * time.Second)
 		}
 		return lines
@@ -142,10 +142,10 @@ func (t *LogWatcher) Start() {
 
 }
 
-func (t *LogWatcher) GetNeutrinoStatus() string {
+func (t *LogWatcher) GetNeutrinoStatus() core.Status {
 	current := t.neutrinoSyncing.current
 	total := t.neutrinoSyncing.total
-	return syncingText(current, total)
+	return syncingStatus(current, total)
 }
 
 func (t *LogWatcher) Stop() {
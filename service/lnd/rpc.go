@@ -10,6 +10,7 @@ import (
 	pb "github.com/ExchangeUnion/xud-docker-api/service/lnd/lnrpc"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 )
 
 var (
@@ -23,15 +24,10 @@ type RpcClient struct {
 	service *core.SingleContainerService
 }
 
-func NewRpcClient(config config.RpcConfig, service *core.SingleContainerService) *RpcClient {
-	host := config["host"].(string)
-	port := uint16(config["port"].(float64))
-	tlsCert := config["tlsCert"].(string)
-	macaroon := config["macaroon"].(string)
-
+func NewRpcClient(config config.GrpcConfig, service *core.SingleContainerService) *RpcClient {
 	logger := service.GetLogger().WithField("name", fmt.Sprintf("service.%s.rpc", service.GetName()))
 
-	conn := rpc.NewGrpcConn(host, port, tlsCert, macaroon, logger, func(conn *grpc.ClientConn) interface{}{
+	conn := rpc.NewGrpcConn(config.Host, config.Port, config.TlsCert, config.Macaroon, logger, func(conn *grpc.ClientConn) interface{}{
 		return pb.NewLightningClient(conn)
 	})
 
@@ -51,6 +47,21 @@ func (t *RpcClient) Close() error {
 	return nil
 }
 
+func (t *RpcClient) GetGrpcStates() map[string]connectivity.State {
+	return map[string]connectivity.State{"default": t.conn.GetState()}
+}
+
+// Reconfigure applies a changed rpc block of config.json. The connection is
+// only reopened if the endpoint has changed.
+func (t *RpcClient) Reconfigure(c config.ServiceConfig) error {
+	rpcConfig, err := c.GrpcConfig()
+	if err != nil {
+		return err
+	}
+	t.conn.Update(rpcConfig.Host, rpcConfig.Port, rpcConfig.TlsCert, rpcConfig.Macaroon)
+	return nil
+}
+
 func (t *RpcClient) getClient() (pb.LightningClient, error) {
 	client := t.conn.GetClient()
 	if client == nil {
@@ -67,3 +78,21 @@ func (t *RpcClient) GetInfo(ctx context.Context) (*pb.GetInfoResponse, error) {
 	req := pb.GetInfoRequest{}
 	return client.GetInfo(ctx, &req)
 }
+
+func (t *RpcClient) ListChannels(ctx context.Context) (*pb.ListChannelsResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.ListChannelsRequest{}
+	return client.ListChannels(ctx, &req)
+}
+
+func (t *RpcClient) PendingChannels(ctx context.Context) (*pb.PendingChannelsResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.PendingChannelsRequest{}
+	return client.PendingChannels(ctx, &req)
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	logBufferSize     = 1000
+	defaultFollowTail = "100"
+	logWriteTimeout   = 10 * time.Second
+
+	defaultSearchSince = "24h"
+	defaultSearchLimit = 1000
+)
+
+var (
+	// the default origin check is kept because the token cookie would
+	// otherwise let any website read the logs
+	logsUpgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+)
+
+// LogLine is a line of a followed service. Lines which had to be dropped
+// because the client was too slow are reported by a line with Dropped set
+// instead of Line.
+type LogLine struct {
+	Service string `json:"service"`
+	Line    string `json:"line,omitempty"`
+	Dropped int64  `json:"dropped,omitempty"`
+}
+
+func (t LogLine) Text(prefix bool) string {
+	if t.Dropped > 0 {
+		if prefix {
+			return fmt.Sprintf("[%s] --- dropped %d lines ---", t.Service, t.Dropped)
+		}
+		return fmt.Sprintf("--- dropped %d lines ---", t.Dropped)
+	}
+	if prefix {
+		return fmt.Sprintf("[%s] %s", t.Service, t.Line)
+	}
+	return t.Line
+}
+
+// LogFollower interleaves the logs of one or more services. Lines are kept
+// in a bounded buffer; when it is full new lines are dropped and counted
+// instead of blocking the containers' log streams.
+type LogFollower struct {
+	lines chan LogLine
+	stops []func()
+	done  chan struct{}
+	once  *sync.Once
+}
+
+// FollowLogs follows the logs of services across container restarts. since
+// and tail are passed to Docker for the first attach of every service.
+func (t *Manager) FollowLogs(services []string, since string, tail string) (*LogFollower, error) {
+	var followers []core.LogFollower
+	for _, name := range services {
+		s, err := t.GetService(name)
+		if err != nil {
+			return nil, err
+		}
+		f, ok := s.(core.LogFollower)
+		if !ok {
+			return nil, errNoContainer(name)
+		}
+		followers = append(followers, f)
+	}
+
+	result := &LogFollower{
+		lines: make(chan LogLine, logBufferSize),
+		done:  make(chan struct{}),
+		once:  &sync.Once{},
+	}
+
+	for i, f := range followers {
+		lines, stop, err := f.FollowLogs2(since, tail)
+		if err != nil {
+			result.Close()
+			return nil, err
+		}
+		result.stops = append(result.stops, stop)
+		go result.forward(services[i], lines)
+	}
+
+	return result, nil
+}
+
+// forward buffers the lines of service. After lines were dropped a notice
+// takes the next free slot, so it shows up where the lines are missing.
+func (t *LogFollower) forward(service string, lines <-chan string) {
+	var dropped int64
+	for line := range lines {
+		if dropped > 0 {
+			select {
+			case t.lines <- LogLine{Service: service, Dropped: dropped}:
+				dropped = 0
+			default:
+			}
+		}
+		if dropped > 0 {
+			dropped++
+			continue
+		}
+		select {
+		case t.lines <- LogLine{Service: service, Line: line}:
+		default:
+			dropped++
+		}
+	}
+}
+
+func (t *LogFollower) Next() (LogLine, bool) {
+	select {
+	case line := <-t.lines:
+		return line, true
+	case <-t.done:
+		return LogLine{}, false
+	}
+}
+
+func (t *LogFollower) Done() <-chan struct{} {
+	return t.done
+}
+
+func (t *LogFollower) Close() {
+	t.once.Do(func() {
+		close(t.done)
+		for _, stop := range t.stops {
+			stop()
+		}
+	})
+}
+
+func (t *Manager) followWebSocket(c *gin.Context, follower *LogFollower, text bool, prefix bool) {
+	conn, err := logsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		t.logger.Errorf("Failed to upgrade to websocket: %s", err)
+		return
+	}
+	defer conn.Close()
+
+	// the client doesn't send anything, reading only detects the close
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				follower.Close()
+				return
+			}
+		}
+	}()
+
+	for {
+		line, ok := follower.Next()
+		if !ok {
+			return
+		}
+		var payload []byte
+		if text {
+			payload = []byte(line.Text(prefix))
+		} else {
+			payload, _ = json.Marshal(line)
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(logWriteTimeout))
+		if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+			return
+		}
+	}
+}
+
+func (t *Manager) followSSE(c *gin.Context, follower *LogFollower, text bool, prefix bool) {
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	lines := make(chan LogLine)
+	go func() {
+		defer close(lines)
+		for {
+			line, ok := follower.Next()
+			if !ok {
+				return
+			}
+			select {
+			case lines <- line:
+			case <-follower.Done():
+				return
+			}
+		}
+	}()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return false
+			}
+			if text {
+				c.SSEvent("log", line.Text(prefix))
+			} else {
+				c.SSEvent("log", line)
+			}
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// LogQuery filters parsed log records. Level is the minimum level, Query
+// and Subsystem are matched case-insensitively.
+type LogQuery struct {
+	Query     string
+	Level     string
+	Subsystem string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+func (t LogQuery) Match(r core.LogRecord) bool {
+	if t.Level != "" && !core.LevelAtLeast(r.Level, t.Level) {
+		return false
+	}
+	if t.Subsystem != "" && !strings.EqualFold(r.Subsystem, t.Subsystem) {
+		return false
+	}
+	if !t.Until.IsZero() && (r.Timestamp == 0 || r.Timestamp > t.Until.Unix()) {
+		return false
+	}
+	if t.Query != "" {
+		q := strings.ToLower(t.Query)
+		if !strings.Contains(strings.ToLower(r.Message), q) && !strings.Contains(strings.ToLower(r.Subsystem), q) {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchLogs parses the logs of service since query.Since and returns the
+// latest query.Limit records matching query.
+func (t *Manager) SearchLogs(service string, query LogQuery) ([]core.LogRecord, error) {
+	s, err := t.GetService(service)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.GetLogs(strconv.FormatInt(query.Since.Unix(), 10), "all")
+	if err != nil {
+		return nil, err
+	}
+	result := []core.LogRecord{}
+	for _, r := range core.ParseLogs(lines, core.GetLogParser(service)) {
+		if query.Match(r) {
+			result = append(result, r)
+		}
+	}
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[len(result)-query.Limit:]
+	}
+	return result, nil
+}
+
+func (t *Manager) configureLogsRouter(r *gin.RouterGroup) {
+	r.GET("/v1/logs/:service/search", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		service := c.Param("service")
+		if _, err := t.GetService(service); err != nil {
+			utils.JsonError(c, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		query := LogQuery{
+			Query:     c.Query("q"),
+			Subsystem: c.Query("subsystem"),
+			Limit:     defaultSearchLimit,
+		}
+		var err error
+		if value := c.Query("level"); value != "" {
+			query.Level = core.NormalizeLevel(value)
+			if query.Level == "" {
+				utils.JsonError(c, "invalid level: "+value, http.StatusBadRequest)
+				return
+			}
+		}
+		query.Since, err = parseSince(c.DefaultQuery("since", defaultSearchSince))
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if value := c.Query("until"); value != "" {
+			query.Until, err = parseSince(value)
+			if err != nil {
+				utils.JsonError(c, "invalid until: "+value, http.StatusBadRequest)
+				return
+			}
+		}
+		if value := c.Query("limit"); value != "" {
+			query.Limit, err = strconv.Atoi(value)
+			if err != nil || query.Limit <= 0 {
+				utils.JsonError(c, "invalid limit: "+value, http.StatusBadRequest)
+				return
+			}
+		}
+
+		records, err := t.SearchLogs(service, query)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, records)
+	})
+
+	// :service is a comma-separated list of services whose logs are
+	// interleaved, e.g. /v1/logs/xud,lndbtc/follow
+	r.GET("/v1/logs/:service/follow", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		services := strings.Split(c.Param("service"), ",")
+		since := c.Query("since")
+		tail := c.DefaultQuery("tail", defaultFollowTail)
+		format := c.DefaultQuery("format", "json")
+		if format != "json" && format != "text" {
+			utils.JsonError(c, "invalid format: "+format, http.StatusBadRequest)
+			return
+		}
+
+		follower, err := t.FollowLogs(services, since, tail)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusNotFound)
+			return
+		}
+		defer follower.Close()
+
+		text := format == "text"
+		prefix := len(services) > 1
+		if websocket.IsWebSocketUpgrade(c.Request) {
+			t.followWebSocket(c, follower, text, prefix)
+		} else {
+			t.followSSE(c, follower, text, prefix)
+		}
+	})
+}
@@ -2,48 +2,58 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/ExchangeUnion/xud-docker-api/config"
-	"github.com/ExchangeUnion/xud-docker-api/service/arby"
-	"github.com/ExchangeUnion/xud-docker-api/service/bitcoind"
-	"github.com/ExchangeUnion/xud-docker-api/service/boltz"
-	"github.com/ExchangeUnion/xud-docker-api/service/connext"
+	_ "github.com/ExchangeUnion/xud-docker-api/service/arby"
+	_ "github.com/ExchangeUnion/xud-docker-api/service/bitcoind"
+	_ "github.com/ExchangeUnion/xud-docker-api/service/boltz"
+	_ "github.com/ExchangeUnion/xud-docker-api/service/connext"
 	"github.com/ExchangeUnion/xud-docker-api/service/core"
-	"github.com/ExchangeUnion/xud-docker-api/service/geth"
-	"github.com/ExchangeUnion/xud-docker-api/service/litecoind"
-	"github.com/ExchangeUnion/xud-docker-api/service/lnd"
-	"github.com/ExchangeUnion/xud-docker-api/service/proxy"
-	"github.com/ExchangeUnion/xud-docker-api/service/webui"
-	"github.com/ExchangeUnion/xud-docker-api/service/xud"
+	"github.com/ExchangeUnion/xud-docker-api/service/generic"
+	_ "github.com/ExchangeUnion/xud-docker-api/service/geth"
+	_ "github.com/ExchangeUnion/xud-docker-api/service/litecoind"
+	_ "github.com/ExchangeUnion/xud-docker-api/service/lnd"
+	_ "github.com/ExchangeUnion/xud-docker-api/service/proxy"
+	_ "github.com/ExchangeUnion/xud-docker-api/service/webui"
+	_ "github.com/ExchangeUnion/xud-docker-api/service/xud"
 	"github.com/docker/docker/api/types"
 	docker "github.com/docker/docker/client"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
-	"io/ioutil"
-)
-
-var (
-	lightProviders = map[string][]string{
-		"testnet": {
-			"http://eth.kilrau.com:52041",
-			"http://michael1011.at:8546",
-			"http://gethxudxv2k4pv5t5a5lswq2hcv3icmj3uwg7m2n2vuykiyv77legiad.onion:8546",
-		},
-		"mainnet": {
-			"http://eth.kilrau.com:41007",
-			"http://michael1011.at:8545",
-			"http://gethxudxv2k4pv5t5a5lswq2hcv3icmj3uwg7m2n2vuykiyv77legiad.onion:8545",
-		},
-	}
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type Manager struct {
-	network   string
-	services  []core.Service
+	network    string
+	configFile string
+	config     *config.Config
+	services   []core.Service
+	serviceMap map[string]core.Service
+	// failed maps the services of config.json which could not be created to
+	// the reason, they are retried on every reload
+	failed    map[string]string
 	factory   core.DockerClientFactory
 	logger    *logrus.Entry
 	listeners map[string]core.DockerEventListener
+	mutex     *sync.RWMutex
+
+	// routed tells if ConfigureRouter was called, the routes of the services
+	// are served by serviceRouter from then on
+	routed        bool
+	routes        map[string][]Route
+	serviceRouter atomic.Value
+	// createdListeners are called with every service created by a reload
+	createdListeners []func(core.Service)
+
+	watcher *ConfigWatcher
+	poller  *StatusPoller
+	history *StatusHistory
+	swaps   *SwapHistory
+
+	dataDirSizes *dataDirSizes
 
 	*LauncherAgent
 }
@@ -52,83 +62,89 @@ func containerName(network string, service string) string {
 	return fmt.Sprintf("%s_%s_1", network, service)
 }
 
-func initServices(network string, dockerClient *docker.Client, listeners map[string]core.DockerEventListener) []core.Service {
+func newService(network string, c config.ServiceConfig, services map[string]core.Service, dockerClient *docker.Client) (core.Service, error) {
+	ctx := core.FactoryContext{
+		Network:       network,
+		ContainerName: containerName(network, c.Name),
+		Services:      services,
+		DockerClient:  dockerClient,
+	}
 
-	f, err := ioutil.ReadFile("/root/network/data/config.json")
-	if err != nil {
-		panic(err)
+	if c.Type == config.DockerOnly {
+		if c.Container != "" {
+			ctx.ContainerName = c.Container
+		}
+		return generic.New(c.Name, services, ctx.ContainerName, dockerClient), nil
+	}
+
+	f, ok := core.GetFactory(c.Name)
+	if !ok {
+		return nil, errors.New("unsupported service")
 	}
+	return f.New(ctx, c)
+}
 
-	var config map[string]interface{}
-	err = json.Unmarshal(f, &config)
+// initServices creates the services in dependency order but returns them in
+// config.json order, which is the order they are listed in the UI. Services
+// which can't be created are logged and skipped, the returned map tells why.
+func initServices(network string, cfg *config.Config, dockerClient *docker.Client, resultMap map[string]core.Service, listeners map[string]core.DockerEventListener, logger *logrus.Entry) ([]core.Service, map[string]string, error) {
+	var names []string
+	for _, c := range cfg.Services {
+		names = append(names, c.Name)
+	}
+	order, err := core.SortByDependencies(names)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
 
-	var result []core.Service
-	var resultMap = make(map[string]core.Service)
-	var s core.Service
-	var name string
-	var cName string
-	var rpc map[string]interface{}
-	var disabled bool
-	var mode string
-
-	for _, item := range config["services"].([]interface{}) {
-		x := item.(map[string]interface{})
-
-		name = x["name"].(string)
-		cName = containerName(network, name)
-		rpc = x["rpc"].(map[string]interface{})
-		disabled = x["disabled"].(bool)
-		if x["mode"] == nil {
-			mode = ""
-		} else {
-			mode = x["mode"].(string)
+	failed := map[string]string{}
+
+	for _, name := range order {
+		c := cfg.GetService(name)
+		for _, dep := range core.GetDependencies(name) {
+			if cfg.GetService(dep) == nil {
+				logger.Debugf("Service %s depends on %s which is not configured", name, dep)
+			}
 		}
-		switch name {
-		case "bitcoind":
-			s = bitcoind.New(name, resultMap, cName, dockerClient, "lndbtc", rpc)
-		case "litecoind":
-			s = litecoind.New(name, resultMap, cName, dockerClient, "lndltc", rpc)
-		case "geth":
-			s = geth.New(name, resultMap, cName, dockerClient, "connext", lightProviders[network], rpc)
-		case "lndbtc":
-			s = lnd.New(name, resultMap, cName, dockerClient, "bitcoin", rpc)
-		case "lndltc":
-			s = lnd.New(name, resultMap, cName, dockerClient, "litecoin", rpc)
-		case "connext":
-			s = connext.New(name, resultMap, cName, dockerClient, rpc)
-		case "xud":
-			s = xud.New(name, resultMap, cName, dockerClient, rpc)
-		case "arby":
-			s = arby.New(name, resultMap, cName, dockerClient, rpc)
-		case "boltz":
-			s = boltz.New(name, resultMap, cName, dockerClient, rpc)
-		case "webui":
-			s = webui.New(name, resultMap, cName, dockerClient)
-		default:
-			panic(errors.New("unsupported service: " + name))
+
+		s, err := newService(network, *c, resultMap, dockerClient)
+		if err != nil {
+			logger.Errorf("Failed to create service %s: %s", c.Name, err)
+			failed[c.Name] = err.Error()
+			continue
 		}
 
-		s.SetDisabled(disabled)
-		s.SetMode(mode)
+		s.SetDisabled(c.Disabled)
+		s.SetMode(c.Mode)
 
-		result = append(result, s)
 		resultMap[s.GetName()] = s
+		listeners[s.GetContainerName()] = s
+	}
 
-		listeners[cName] = s
+	var result []core.Service
+	for _, name := range names {
+		if s, ok := resultMap[name]; ok {
+			result = append(result, s)
+		}
 	}
 
 	// add self
-	s = proxy.New("proxy", resultMap, containerName(network, "proxy"), dockerClient)
+	s, err := newService(network, config.ServiceConfig{Name: "proxy"}, resultMap, dockerClient)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create service proxy: %s", err)
+	}
 	result = append(result, s)
 	resultMap[s.GetName()] = s
 
-	return result
+	return result, failed, nil
 }
 
-func NewManager(network string) (*Manager, error) {
+func NewManager(network string, configFile string, dataDir string) (*Manager, error) {
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return nil, err
+	}
+
 	factory, err := core.NewClientFactory()
 	if err != nil {
 		return nil, err
@@ -136,35 +152,144 @@ func NewManager(network string) (*Manager, error) {
 
 	logger := logrus.NewEntry(logrus.StandardLogger()).WithField("name", "ServiceManager")
 
+	serviceMap := map[string]core.Service{}
 	listeners := map[string]core.DockerEventListener{}
 
+	services, failed, err := initServices(network, cfg, factory.GetSharedInstance(), serviceMap, listeners, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := NewStatusHistory(dataDir, logger.WithField("name", "StatusHistory"))
+	if err != nil {
+		return nil, err
+	}
+
+	swaps, err := NewSwapHistory(dataDir, logger.WithField("name", "SwapHistory"))
+	if err != nil {
+		return nil, err
+	}
+
 	manager := Manager{
 		network:       network,
-		services:      initServices(network, factory.GetSharedInstance(), listeners),
+		configFile:    configFile,
+		config:        cfg,
+		services:      services,
+		serviceMap:    serviceMap,
+		failed:        failed,
 		factory:       factory,
 		logger:        logger,
 		listeners:     listeners,
+		mutex:         &sync.RWMutex{},
+		history:       history,
+		swaps:         swaps,
+		dataDirSizes:  newDataDirSizes(),
 		LauncherAgent: NewLauncherAgent(network, logger.WithField("name", "LauncherAgent")),
 	}
+	manager.watcher = NewConfigWatcher(&manager, logger.WithField("name", "ConfigWatcher"))
+	manager.poller = NewStatusPoller(&manager, logger.WithField("name", "StatusPoller"))
 
 	go manager.listenForDockerEvents()
+	go manager.watcher.Watch()
+	if err := prometheus.Register(&metricsCollector{manager: &manager}); err != nil {
+		return nil, err
+	}
+
+	go manager.recordHistory()
+	go manager.recordSwaps()
+	go manager.poller.Run()
 
 	return &manager, nil
 }
 
 func (t *Manager) getServices() []core.Service {
-	return t.services
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	result := make([]core.Service, len(t.services))
+	copy(result, t.services)
+	return result
+}
+
+// OnServiceCreated calls f with every current service and with the services
+// created by later reloads, which replace the instances that were passed
+// before. f is called with the manager locked.
+func (t *Manager) OnServiceCreated(f func(core.Service)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.createdListeners = append(t.createdListeners, f)
+	for _, s := range t.services {
+		f(s)
+	}
+}
+
+func (t *Manager) getListener(containerName string) (core.DockerEventListener, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	l, ok := t.listeners[containerName]
+	return l, ok
 }
 
 type StatusResult struct {
 	Service string
-	Status  string
+	Status  core.Status
+}
+
+// GetStatus returns the latest status of all services from the StatusPoller
+// and an error status for the services which could not be created.
+func (t *Manager) GetStatus() map[string]core.Status {
+	result := t.poller.GetStatus()
+	for name, reason := range t.getFailed() {
+		result[name] = failedStatus(reason)
+	}
+	return result
 }
 
-func (t *Manager) GetStatus() map[string]string {
+func failedStatus(reason string) core.Status {
+	s := core.NewStatus(core.StateError, "Failed to create service")
+	s.Error = reason
+	return s
+}
+
+// getFailed returns the services which could not be created.
+func (t *Manager) getFailed() map[string]string {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
 	result := map[string]string{}
+	for name, reason := range t.failed {
+		result[name] = reason
+	}
+	return result
+}
+
+// getStatusNames returns the names of all services which have a status, in
+// config.json order followed by proxy.
+func (t *Manager) getStatusNames() []string {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	var result []string
+	for _, c := range t.config.Services {
+		_, created := t.serviceMap[c.Name]
+		_, failed := t.failed[c.Name]
+		if created || failed {
+			result = append(result, c.Name)
+		}
+	}
+	if _, ok := t.serviceMap[config.ProxyService]; ok {
+		result = append(result, config.ProxyService)
+	}
+	return result
+}
+
+func (t *Manager) SubscribeStatus() (<-chan ServiceStatusV2, func()) {
+	return t.poller.Subscribe()
+}
+
+// fetchStatus queries the status of all services concurrently.
+func (t *Manager) fetchStatus() map[string]core.Status {
+	result := map[string]core.Status{}
 	ch := make(chan StatusResult)
-	for _, svc := range t.services {
+	services := t.getServices()
+	for _, svc := range services {
 		s := svc
 		go func() {
 			ctx := context.WithValue(context.Background(), "LauncherState", t.LauncherAgent.GetState())
@@ -176,7 +301,7 @@ func (t *Manager) GetStatus() map[string]string {
 		}()
 	}
 
-	for i := 0; i < len(t.services); i++ {
+	for i := 0; i < len(services); i++ {
 		r := <-ch
 		result[r.Service] = r.Status
 	}
@@ -184,8 +309,28 @@ func (t *Manager) GetStatus() map[string]string {
 	return result
 }
 
+func (t *Manager) GetServiceStatus(name string) (*core.Status, error) {
+	if reason, ok := t.getFailed()[name]; ok {
+		status := failedStatus(reason)
+		return &status, nil
+	}
+	s, err := t.GetService(name)
+	if err != nil {
+		return nil, err
+	}
+	if status, ok := t.GetStatus()[name]; ok {
+		return &status, nil
+	}
+	// not polled yet (e.g. just added by a config reload)
+	ctx := context.WithValue(context.Background(), "LauncherState", t.LauncherAgent.GetState())
+	ctx, cancel := context.WithTimeout(ctx, config.DefaultApiTimeout)
+	defer cancel()
+	status := s.GetStatus(ctx)
+	return &status, nil
+}
+
 func (t *Manager) GetService(name string) (core.Service, error) {
-	for _, svc := range t.services {
+	for _, svc := range t.getServices() {
 		if svc.GetName() == name {
 			return svc, nil
 		}
@@ -193,9 +338,66 @@ func (t *Manager) GetService(name string) (core.Service, error) {
 	return nil, errors.New("service not found: " + name)
 }
 
+type Route struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
 type ServiceEntry struct {
-	Id   string `json:"id"`
-	Name string `json:"name"`
+	Id            string  `json:"id"`
+	Name          string  `json:"name"`
+	ContainerName string  `json:"containerName"`
+	ContainerId   string  `json:"containerId"`
+	Image         string  `json:"image"`
+	Tag           string  `json:"tag"`
+	Mode          string  `json:"mode"`
+	Disabled      bool    `json:"disabled"`
+	Routes        []Route `json:"routes"`
+}
+
+// splitImage splits "registry:5000/org/name:tag" into the image name and tag.
+func splitImage(image string) (string, string) {
+	i := strings.LastIndex(image, ":")
+	if i == -1 || strings.Contains(image[i:], "/") {
+		return image, "latest"
+	}
+	return image[:i], image[i+1:]
+}
+
+func (t *Manager) GetServiceEntries() []ServiceEntry {
+	var result []ServiceEntry
+	for _, s := range t.getServices() {
+		name := s.GetName()
+		displayName := t.getDisplayName(name)
+		entry := ServiceEntry{
+			Id:            name,
+			Name:          displayName,
+			ContainerName: s.GetContainerName(),
+			ContainerId:   s.GetContainerId(),
+			Mode:          s.GetMode(),
+			Disabled:      s.IsDisabled(),
+			Routes:        []Route{},
+		}
+		if image := s.GetImage(); image != "" {
+			entry.Image, entry.Tag = splitImage(image)
+		}
+		t.mutex.RLock()
+		if routes, ok := t.routes[name]; ok {
+			entry.Routes = routes
+		}
+		t.mutex.RUnlock()
+		result = append(result, entry)
+	}
+	return result
+}
+
+func (t *Manager) getDisplayName(name string) string {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	if c := t.config.GetService(name); c != nil && c.DisplayName != "" {
+		return c.DisplayName
+	}
+	return core.GetDisplayName(name)
 }
 
 type ServiceStatus struct {
@@ -203,13 +405,25 @@ type ServiceStatus struct {
 	Status  string `json:"status"`
 }
 
+// ServiceStatusV2 is the /api/v2/status representation of a service status.
+type ServiceStatusV2 struct {
+	Service string `json:"service"`
+	core.Status
+}
+
 func (t *Manager) Close() error {
-	for _, s := range t.services {
+	for _, s := range t.getServices() {
 		err := s.Close()
 		if err != nil {
 			return fmt.Errorf("failed to close service %s: %s", s.GetName(), err)
 		}
 	}
+	if err := t.history.Close(); err != nil {
+		return fmt.Errorf("failed to close status history: %s", err)
+	}
+	if err := t.swaps.Close(); err != nil {
+		return fmt.Errorf("failed to close swap history: %s", err)
+	}
 	return nil
 }
 
@@ -240,24 +454,24 @@ ListenLoop:
 				switch event.Action {
 				case "create":
 					name = t.id2name(event.ID)
-					s, ok := t.listeners[name]
+					s, ok := t.getListener(name)
 					if ok {
 						s.OnEvent("create")
 					}
 				case "start":
 					name = t.id2name(event.ID)
-					s, ok := t.listeners[name]
+					s, ok := t.getListener(name)
 					if ok {
 						s.OnEvent("start")
 					}
 				case "die":
 					name = t.id2name(event.ID)
-					s, ok := t.listeners[name]
+					s, ok := t.getListener(name)
 					if ok {
 						s.OnEvent("die")
 					}
 				case "destroy":
-					for _, s := range t.services {
+					for _, s := range t.getServices() {
 						if s.GetContainerId() == event.ID {
 							s.OnEvent("die")
 							break
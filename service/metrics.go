@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"github.com/ExchangeUnion/xud-docker-api/service/xud"
+	pb "github.com/ExchangeUnion/xud-docker-api/service/xud/xudrpc"
+	"github.com/docker/docker/api/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/connectivity"
+)
+
+const (
+	metricsNamespace = "proxy"
+)
+
+var (
+	allStates = []core.State{
+		core.StateDisabled,
+		core.StateMissing,
+		core.StateStopped,
+		core.StateStarting,
+		core.StateSyncing,
+		core.StateLocked,
+		core.StateWaiting,
+		core.StateReady,
+		core.StateError,
+	}
+
+	grpcStates = []connectivity.State{
+		connectivity.Idle,
+		connectivity.Connecting,
+		connectivity.Ready,
+		connectivity.TransientFailure,
+		connectivity.Shutdown,
+	}
+
+	serviceReadyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "service", "ready"),
+		"Whether the service is ready (1) or not (0).",
+		[]string{"service"}, nil,
+	)
+	serviceStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "service", "state"),
+		"The current state of the service (1 for the current state, 0 otherwise).",
+		[]string{"service", "state"}, nil,
+	)
+	syncCurrentDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "service", "sync_current_height"),
+		"Current block height of a syncing service.",
+		[]string{"service"}, nil,
+	)
+	syncTotalDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "service", "sync_total_height"),
+		"Target block height of a syncing service.",
+		[]string{"service"}, nil,
+	)
+	syncProgressDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "service", "sync_progress_ratio"),
+		"Sync progress of the service between 0 and 1. Ready services report 1.",
+		[]string{"service"}, nil,
+	)
+	restartsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "container", "restarts"),
+		"Number of times Docker restarted the container of the service.",
+		[]string{"service"}, nil,
+	)
+	grpcStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "grpc", "connection_state"),
+		"The state of the gRPC connection to the service (1 for the current state, 0 otherwise).",
+		[]string{"service", "conn", "state"}, nil,
+	)
+	xudBalanceDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "xud", "balance_satoshis"),
+		"xud balance per currency.",
+		[]string{"currency", "type"}, nil,
+	)
+	xudTradingLimitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "xud", "trading_limit_satoshis"),
+		"xud trading limits per currency.",
+		[]string{"currency", "type"}, nil,
+	)
+	xudOpenOrdersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "xud", "open_orders"),
+		"Number of own open orders per trading pair and side.",
+		[]string{"pair", "side"}, nil,
+	)
+)
+
+// metricsCollector exports the state of the services at scrape time. Service
+// status comes from the StatusPoller cache, so a scrape does not cause any
+// additional RPC calls except for the xud balances, limits and orders.
+type metricsCollector struct {
+	manager *Manager
+}
+
+type containerService interface {
+	GetContainer() (*types.ContainerJSON, error)
+}
+
+func (t *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- serviceReadyDesc
+	ch <- serviceStateDesc
+	ch <- syncCurrentDesc
+	ch <- syncTotalDesc
+	ch <- syncProgressDesc
+	ch <- restartsDesc
+	ch <- grpcStateDesc
+	ch <- xudBalanceDesc
+	ch <- xudTradingLimitDesc
+	ch <- xudOpenOrdersDesc
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func (t *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	status := t.manager.GetStatus()
+
+	for _, s := range t.manager.getServices() {
+		name := s.GetName()
+
+		if st, ok := status[name]; ok {
+			ch <- prometheus.MustNewConstMetric(serviceReadyDesc, prometheus.GaugeValue, boolValue(st.IsReady()), name)
+			for _, state := range allStates {
+				ch <- prometheus.MustNewConstMetric(serviceStateDesc, prometheus.GaugeValue, boolValue(st.State == state), name, string(state))
+			}
+			if st.Progress != nil {
+				ch <- prometheus.MustNewConstMetric(syncCurrentDesc, prometheus.GaugeValue, float64(st.Progress.Current), name)
+				ch <- prometheus.MustNewConstMetric(syncTotalDesc, prometheus.GaugeValue, float64(st.Progress.Total), name)
+				ch <- prometheus.MustNewConstMetric(syncProgressDesc, prometheus.GaugeValue, st.Progress.Percent/100.0, name)
+			} else if st.IsReady() {
+				ch <- prometheus.MustNewConstMetric(syncProgressDesc, prometheus.GaugeValue, 1, name)
+			}
+		}
+
+		if cs, ok := s.(containerService); ok {
+			if c, err := cs.GetContainer(); err == nil {
+				ch <- prometheus.MustNewConstMetric(restartsDesc, prometheus.CounterValue, float64(c.RestartCount), name)
+			}
+		}
+
+		if gs, ok := s.(core.GrpcService); ok {
+			for conn, current := range gs.GetGrpcStates() {
+				for _, state := range grpcStates {
+					ch <- prometheus.MustNewConstMetric(grpcStateDesc, prometheus.GaugeValue, boolValue(current == state), name, conn, state.String())
+				}
+			}
+		}
+	}
+
+	if st, ok := status["xud"]; ok && st.IsReady() {
+		if s, err := t.manager.GetService("xud"); err == nil {
+			// a "docker" entry named xud has no API to collect from
+			if x, ok := s.(*xud.Service); ok {
+				t.collectXud(ch, x)
+			}
+		}
+	}
+}
+
+func (t *metricsCollector) collectXud(ch chan<- prometheus.Metric, s *xud.Service) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+	defer cancel()
+
+	if resp, err := s.GetBalance(ctx, ""); err == nil {
+		for currency, b := range resp.Balances {
+			values := map[string]uint64{
+				"total":              b.TotalBalance,
+				"channel":            b.ChannelBalance,
+				"pending_channel":    b.PendingChannelBalance,
+				"inactive_channel":   b.InactiveChannelBalance,
+				"wallet":             b.WalletBalance,
+				"unconfirmed_wallet": b.UnconfirmedWalletBalance,
+			}
+			for kind, value := range values {
+				ch <- prometheus.MustNewConstMetric(xudBalanceDesc, prometheus.GaugeValue, float64(value), currency, kind)
+			}
+		}
+	} else {
+		t.manager.logger.Debugf("Failed to collect xud balances: %s", err)
+	}
+
+	if resp, err := s.GetTradingLimits(ctx, ""); err == nil {
+		for currency, l := range resp.Limits {
+			values := map[string]uint64{
+				"max_sell":      l.MaxSell,
+				"max_buy":       l.MaxBuy,
+				"reserved_sell": l.ReservedSell,
+				"reserved_buy":  l.ReservedBuy,
+			}
+			for kind, value := range values {
+				ch <- prometheus.MustNewConstMetric(xudTradingLimitDesc, prometheus.GaugeValue, float64(value), currency, kind)
+			}
+		}
+	} else {
+		t.manager.logger.Debugf("Failed to collect xud trading limits: %s", err)
+	}
+
+	if resp, err := s.ListOrders(ctx, "", pb.ListOrdersRequest_OWN, 0, false); err == nil {
+		for pair, orders := range resp.Orders {
+			ch <- prometheus.MustNewConstMetric(xudOpenOrdersDesc, prometheus.GaugeValue, float64(len(orders.BuyOrders)), pair, "buy")
+			ch <- prometheus.MustNewConstMetric(xudOpenOrdersDesc, prometheus.GaugeValue, float64(len(orders.SellOrders)), pair, "sell")
+		}
+	} else {
+		t.manager.logger.Debugf("Failed to collect xud orders: %s", err)
+	}
+}
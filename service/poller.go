@@ -0,0 +1,145 @@
+package service
+
+import (
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	statusPollInterval = 5 * time.Second
+)
+
+// StatusPoller refreshes the status of all services in the background and
+// keeps the latest one per service, so that API requests never have to fan
+// out GetStatus calls themselves. Subscribers only receive changes.
+type StatusPoller struct {
+	manager     *Manager
+	cache       map[string]core.Status
+	polled      bool
+	broadcaster *utils.Broadcaster
+	mutex       *sync.RWMutex
+	pollMutex   *sync.Mutex
+	logger      *logrus.Entry
+}
+
+func NewStatusPoller(manager *Manager, logger *logrus.Entry) *StatusPoller {
+	return &StatusPoller{
+		manager:     manager,
+		cache:       map[string]core.Status{},
+		broadcaster: utils.NewBroadcaster(),
+		mutex:       &sync.RWMutex{},
+		pollMutex:   &sync.Mutex{},
+		logger:      logger,
+	}
+}
+
+func (t *StatusPoller) Run() {
+	ticker := time.NewTicker(statusPollInterval)
+	defer ticker.Stop()
+	t.Poll()
+	for range ticker.C {
+		t.Poll()
+	}
+}
+
+func sameStatus(a core.Status, b core.Status) bool {
+	if a.State != b.State || a.Message != b.Message || a.Error != b.Error {
+		return false
+	}
+	if a.Progress == nil || b.Progress == nil {
+		return a.Progress == b.Progress
+	}
+	return *a.Progress == *b.Progress
+}
+
+// Poll fetches the status of all services and emits the changed ones.
+func (t *StatusPoller) Poll() {
+	t.pollMutex.Lock()
+	defer t.pollMutex.Unlock()
+
+	status := t.manager.fetchStatus()
+
+	var changes []ServiceStatusV2
+
+	t.mutex.Lock()
+	for name, s := range status {
+		old, ok := t.cache[name]
+		if !ok || !sameStatus(old, s) {
+			changes = append(changes, ServiceStatusV2{Service: name, Status: s})
+		}
+	}
+	t.cache = status
+	t.polled = true
+	t.mutex.Unlock()
+
+	for _, change := range changes {
+		t.logger.Debugf("%s: %s", change.Service, change.Message)
+		t.emit(change)
+	}
+}
+
+func (t *StatusPoller) emit(status ServiceStatusV2) {
+	if t.broadcaster.Emit(status) > 0 {
+		t.logger.Warn("Dropped status update for a slow subscriber")
+	}
+}
+
+// GetStatus returns the cached status of all services. It only blocks if the
+// first poll has not finished yet.
+func (t *StatusPoller) GetStatus() map[string]core.Status {
+	t.mutex.RLock()
+	polled := t.polled
+	t.mutex.RUnlock()
+	if !polled {
+		t.Poll()
+	}
+
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	result := make(map[string]core.Status, len(t.cache))
+	for name, s := range t.cache {
+		result[name] = s
+	}
+	return result
+}
+
+func (t *StatusPoller) Subscribe() (<-chan ServiceStatusV2, func()) {
+	ch := make(chan ServiceStatusV2, 100)
+	return ch, t.broadcaster.Add(ch)
+}
+
+func (t *StatusPoller) ConfigureRouter(r *gin.RouterGroup) {
+	r.GET("/v2/status-events", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		updates, cancel := t.Subscribe()
+		defer cancel()
+
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+
+		// start with a snapshot so that clients don't need another request
+		status := t.GetStatus()
+		for _, svc := range t.manager.getServices() {
+			name := svc.GetName()
+			if s, ok := status[name]; ok {
+				c.SSEvent("status", ServiceStatusV2{Service: name, Status: s})
+			}
+		}
+		c.Writer.Flush()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case status := <-updates:
+				c.SSEvent("status", status)
+				return true
+			case <-c.Request.Context().Done():
+				return false
+			}
+		})
+	})
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	"github.com/ExchangeUnion/xud-docker-api/service/boltz"
+	"github.com/ExchangeUnion/xud-docker-api/service/connext"
+	"github.com/ExchangeUnion/xud-docker-api/service/lnd"
+	"github.com/ExchangeUnion/xud-docker-api/service/xud"
+	pb "github.com/ExchangeUnion/xud-docker-api/service/xud/xudrpc"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// All amounts of the portfolio are in satoshis (10^-8 units), like in xud.
+
+type OnChainBalance struct {
+	Confirmed   int64 `json:"confirmed"`
+	Unconfirmed int64 `json:"unconfirmed"`
+}
+
+// ChannelSides are the balances of our channels. The remote side is only
+// known for lnd currencies: xud reports just our side of Connext channels, so
+// Remote is 0 for them.
+type ChannelSides struct {
+	Local  int64 `json:"local"`
+	Remote int64 `json:"remote"`
+}
+
+// PendingBalance are funds which are ours but not spendable yet: channels
+// waiting for confirmation, funds of closing channels (limbo) and Boltz swaps
+// in transit.
+type PendingBalance struct {
+	Channel int64 `json:"channel"`
+	Limbo   int64 `json:"limbo"`
+	Boltz   int64 `json:"boltz"`
+}
+
+// ReservedBalance are the amounts xud reserves for our open orders.
+type ReservedBalance struct {
+	Outbound int64 `json:"outbound"`
+	Inbound  int64 `json:"inbound"`
+}
+
+// AssetBalance is everything we hold of a currency. Total is the sum of the
+// on-chain, local channel and pending amounts. RealizedPnl is the realized
+// profit of all pairs quoted in this currency.
+type AssetBalance struct {
+	Currency    string          `json:"currency"`
+	Total       int64           `json:"total"`
+	OnChain     OnChainBalance  `json:"onChain"`
+	Channel     ChannelSides    `json:"channel"`
+	Pending     PendingBalance  `json:"pending"`
+	Reserved    ReservedBalance `json:"reserved"`
+	RealizedPnl int64           `json:"realizedPnl"`
+}
+
+// PairPnl is the result of matching the sells of a pair against its buys
+// first in, first out. Realized and OpenCost are in the quote currency, the
+// quantities in the base currency. UnmatchedQuantity was sold without buys
+// before it, so its cost basis is unknown and it isn't part of Realized.
+type PairPnl struct {
+	PairId            string `json:"pairId"`
+	Realized          int64  `json:"realized"`
+	Bought            uint64 `json:"bought"`
+	Sold              uint64 `json:"sold"`
+	OpenQuantity      uint64 `json:"openQuantity"`
+	OpenCost          int64  `json:"openCost"`
+	UnmatchedQuantity uint64 `json:"unmatchedQuantity"`
+}
+
+type Portfolio struct {
+	Assets []AssetBalance `json:"assets"`
+	Pairs  []PairPnl      `json:"pairs"`
+	Errors []string       `json:"errors,omitempty"`
+}
+
+type lot struct {
+	quantity uint64
+	price    *big.Rat
+}
+
+func ratToSatoshis(r *big.Rat) int64 {
+	f, _ := r.Float64()
+	if f < 0 {
+		return int64(f - 0.5)
+	}
+	return int64(f + 0.5)
+}
+
+// ComputePnl matches the trades of every pair first in, first out. Internal
+// trades are skipped since both sides are ours.
+func ComputePnl(trades []*pb.Trade) []PairPnl {
+	sorted := make([]*pb.Trade, len(trades))
+	copy(sorted, trades)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ExecutedAt < sorted[j].ExecutedAt
+	})
+
+	lots := map[string][]lot{}
+	realized := map[string]*big.Rat{}
+	pairs := map[string]*PairPnl{}
+
+	for _, trade := range sorted {
+		if trade.Side == pb.OrderSide_BOTH {
+			continue
+		}
+		p, ok := pairs[trade.PairId]
+		if !ok {
+			p = &PairPnl{PairId: trade.PairId}
+			pairs[trade.PairId] = p
+			realized[trade.PairId] = new(big.Rat)
+		}
+		price := new(big.Rat).SetFloat64(trade.Price)
+		if price == nil {
+			continue
+		}
+
+		if trade.Side == pb.OrderSide_BUY {
+			p.Bought += trade.Quantity
+			lots[trade.PairId] = append(lots[trade.PairId], lot{quantity: trade.Quantity, price: price})
+			continue
+		}
+
+		p.Sold += trade.Quantity
+		remaining := trade.Quantity
+		queue := lots[trade.PairId]
+		for remaining > 0 && len(queue) > 0 {
+			matched := queue[0].quantity
+			if matched > remaining {
+				matched = remaining
+			}
+			diff := new(big.Rat).Sub(price, queue[0].price)
+			diff.Mul(diff, new(big.Rat).SetInt(new(big.Int).SetUint64(matched)))
+			realized[trade.PairId].Add(realized[trade.PairId], diff)
+
+			remaining -= matched
+			queue[0].quantity -= matched
+			if queue[0].quantity == 0 {
+				queue = queue[1:]
+			}
+		}
+		lots[trade.PairId] = queue
+		p.UnmatchedQuantity += remaining
+	}
+
+	result := []PairPnl{}
+	for pairId, p := range pairs {
+		p.Realized = ratToSatoshis(realized[pairId])
+		cost := new(big.Rat)
+		for _, l := range lots[pairId] {
+			p.OpenQuantity += l.quantity
+			cost.Add(cost, new(big.Rat).Mul(l.price, new(big.Rat).SetInt(new(big.Int).SetUint64(l.quantity))))
+		}
+		p.OpenCost = ratToSatoshis(cost)
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PairId < result[j].PairId
+	})
+	return result
+}
+
+// boltzPending sums the swaps of the boltz-client of currency whose funds
+// have left our wallet or channels but haven't arrived yet.
+func boltzPending(ctx context.Context, s *boltz.Service, currency string) (int64, error) {
+	info, err := s.GetClientInfo(ctx, currency)
+	if err != nil {
+		return 0, err
+	}
+	if len(info.PendingSwaps) == 0 && len(info.PendingReverseSwaps) == 0 {
+		return 0, nil
+	}
+	pending := map[string]bool{}
+	for _, id := range append(info.PendingSwaps, info.PendingReverseSwaps...) {
+		pending[id] = true
+	}
+
+	swaps, err := s.ListSwaps(ctx, currency)
+	if err != nil {
+		return 0, err
+	}
+	var result int64
+	submarine := swaps.Swaps
+	for _, c := range swaps.ChannelCreations {
+		if c.Swap != nil {
+			submarine = append(submarine, c.Swap)
+		}
+	}
+	for _, swap := range submarine {
+		// the swap waits for a deposit until it has a lockup transaction
+		if pending[swap.Id] && swap.LockupTransactionId != "" {
+			result += swap.ExpectedAmount
+		}
+	}
+	for _, swap := range swaps.ReverseSwaps {
+		// the invoice is paid when the reverse swap is created
+		if pending[swap.Id] {
+			result += swap.OnchainAmount
+		}
+	}
+	return result, nil
+}
+
+// GetPortfolio aggregates the balances of xud, lnd and Boltz per currency and
+// the realized profit of the trade history. Connext itself isn't queried, the
+// balances of Connext currencies are the ones xud reports. If connext is
+// down they may be out of date, which is listed in the errors.
+func (t *Manager) GetPortfolio(ctx context.Context) (*Portfolio, error) {
+	s, err := t.GetService("xud")
+	if err != nil {
+		return nil, err
+	}
+	x, ok := s.(*xud.Service)
+	if !ok {
+		return nil, errors.New("xud is not available")
+	}
+
+	balances, err := x.GetBalance(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Portfolio{Assets: []AssetBalance{}, Pairs: []PairPnl{}}
+	addError := func(format string, args ...interface{}) {
+		result.Errors = append(result.Errors, fmt.Sprintf(format, args...))
+	}
+
+	limits, err := x.GetTradingLimits(ctx, "")
+	if err != nil {
+		addError("xud trading limits: %s", err)
+	}
+
+	if currencies, err := x.ListCurrencies(ctx); err != nil {
+		addError("xud currencies: %s", err)
+	} else {
+		var connextCurrencies []string
+		for _, c := range currencies.Currencies {
+			if c.SwapClient == pb.Currency_CONNEXT {
+				connextCurrencies = append(connextCurrencies, c.Currency)
+			}
+		}
+		if len(connextCurrencies) > 0 && !t.isConnextHealthy(ctx) {
+			addError("connext is not available, the balances of %s may be out of date", strings.Join(connextCurrencies, ", "))
+		}
+	}
+
+	var boltzService *boltz.Service
+	if s, err := t.GetService("boltz"); err == nil {
+		boltzService, _ = s.(*boltz.Service)
+	}
+
+	var currencies []string
+	for currency := range balances.Balances {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	// indexes of the assets by currency
+	assets := map[string]int{}
+	for _, currency := range currencies {
+		b := balances.Balances[currency]
+		asset := AssetBalance{
+			Currency: currency,
+			OnChain: OnChainBalance{
+				Confirmed:   int64(b.WalletBalance),
+				Unconfirmed: int64(b.UnconfirmedWalletBalance),
+			},
+			Channel: ChannelSides{Local: int64(b.ChannelBalance + b.InactiveChannelBalance)},
+			Pending: PendingBalance{Channel: int64(b.PendingChannelBalance)},
+		}
+
+		if limits != nil {
+			if l, ok := limits.Limits[currency]; ok {
+				asset.Reserved = ReservedBalance{Outbound: int64(l.ReservedSell), Inbound: int64(l.ReservedBuy)}
+			}
+		}
+
+		// lnd knows the remote side of the channels and the funds of
+		// closing channels
+		name := "lnd" + strings.ToLower(currency)
+		if s, err := t.GetService(name); err == nil {
+			if l, ok := s.(*lnd.Service); ok {
+				if channels, err := l.ListChannels(ctx); err != nil {
+					addError("%s channels: %s", name, err)
+				} else {
+					asset.Channel = ChannelSides{}
+					for _, c := range channels.Channels {
+						asset.Channel.Local += c.LocalBalance
+						asset.Channel.Remote += c.RemoteBalance
+					}
+				}
+				if pending, err := l.PendingChannels(ctx); err != nil {
+					addError("%s pending channels: %s", name, err)
+				} else {
+					asset.Pending.Limbo = pending.TotalLimboBalance
+				}
+			}
+		}
+
+		if boltzService != nil && (currency == "BTC" || currency == "LTC") {
+			if amount, err := boltzPending(ctx, boltzService, currency); err != nil {
+				addError("boltz %s: %s", currency, err)
+			} else {
+				asset.Pending.Boltz = amount
+			}
+		}
+
+		asset.Total = asset.OnChain.Confirmed + asset.OnChain.Unconfirmed + asset.Channel.Local +
+			asset.Pending.Channel + asset.Pending.Limbo + asset.Pending.Boltz
+		assets[currency] = len(result.Assets)
+		result.Assets = append(result.Assets, asset)
+	}
+
+	history, err := x.GetTradeHistory(ctx, 0)
+	if err != nil {
+		addError("xud trade history: %s", err)
+	} else {
+		result.Pairs = ComputePnl(history.Trades)
+		for _, p := range result.Pairs {
+			parts := strings.SplitN(p.PairId, "/", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			if i, ok := assets[parts[1]]; ok {
+				result.Assets[i].RealizedPnl += p.Realized
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (t *Manager) isConnextHealthy(ctx context.Context) bool {
+	s, err := t.GetService("connext")
+	if err != nil {
+		return false
+	}
+	c, ok := s.(*connext.Service)
+	return ok && c.IsHealthy(ctx)
+}
+
+func (t *Manager) configurePortfolioRouter(r *gin.RouterGroup) {
+	r.GET("/v1/portfolio", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		portfolio, err := t.GetPortfolio(ctx)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, portfolio)
+	})
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	pb "github.com/ExchangeUnion/xud-docker-api/service/xud/xudrpc"
+	"reflect"
+	"testing"
+)
+
+func trade(pairId string, side pb.OrderSide, quantity uint64, price float64, executedAt uint64) *pb.Trade {
+	return &pb.Trade{PairId: pairId, Side: side, Quantity: quantity, Price: price, ExecutedAt: executedAt}
+}
+
+func TestComputePnl(t *testing.T) {
+	const (
+		buy  = pb.OrderSide_BUY
+		sell = pb.OrderSide_SELL
+		both = pb.OrderSide_BOTH
+	)
+	tests := []struct {
+		name   string
+		trades []*pb.Trade
+		want   []PairPnl
+	}{
+		{name: "no trades", trades: nil, want: []PairPnl{}},
+		{
+			name: "open position",
+			trades: []*pb.Trade{
+				trade("LTC/BTC", buy, 100000000, 0.005, 1),
+			},
+			want: []PairPnl{
+				{PairId: "LTC/BTC", Bought: 100000000, OpenQuantity: 100000000, OpenCost: 500000},
+			},
+		},
+		{
+			name: "round trip",
+			trades: []*pb.Trade{
+				trade("LTC/BTC", buy, 100000000, 0.005, 1),
+				trade("LTC/BTC", sell, 100000000, 0.006, 2),
+			},
+			want: []PairPnl{
+				{PairId: "LTC/BTC", Realized: 100000, Bought: 100000000, Sold: 100000000},
+			},
+		},
+		{
+			name: "first in, first out",
+			trades: []*pb.Trade{
+				trade("LTC/BTC", buy, 100000000, 0.004, 1),
+				trade("LTC/BTC", buy, 100000000, 0.006, 2),
+				// matches all of the first lot and half of the second
+				trade("LTC/BTC", sell, 150000000, 0.005, 3),
+			},
+			want: []PairPnl{
+				// +0.001 * 1 - 0.001 * 0.5
+				{PairId: "LTC/BTC", Realized: 50000, Bought: 200000000, Sold: 150000000, OpenQuantity: 50000000, OpenCost: 300000},
+			},
+		},
+		{
+			name: "loss",
+			trades: []*pb.Trade{
+				trade("ETH/BTC", buy, 200000000, 0.04, 1),
+				trade("ETH/BTC", sell, 100000000, 0.03, 2),
+			},
+			want: []PairPnl{
+				{PairId: "ETH/BTC", Realized: -1000000, Bought: 200000000, Sold: 100000000, OpenQuantity: 100000000, OpenCost: 4000000},
+			},
+		},
+		{
+			name: "unordered",
+			trades: []*pb.Trade{
+				trade("LTC/BTC", sell, 100000000, 0.006, 2),
+				trade("LTC/BTC", buy, 100000000, 0.005, 1),
+			},
+			want: []PairPnl{
+				{PairId: "LTC/BTC", Realized: 100000, Bought: 100000000, Sold: 100000000},
+			},
+		},
+		{
+			name: "sell without buys",
+			trades: []*pb.Trade{
+				trade("LTC/BTC", sell, 100000000, 0.006, 1),
+				trade("LTC/BTC", buy, 100000000, 0.005, 2),
+			},
+			want: []PairPnl{
+				{PairId: "LTC/BTC", Bought: 100000000, Sold: 100000000, OpenQuantity: 100000000, OpenCost: 500000, UnmatchedQuantity: 100000000},
+			},
+		},
+		{
+			name: "partially unmatched",
+			trades: []*pb.Trade{
+				trade("LTC/BTC", buy, 50000000, 0.005, 1),
+				trade("LTC/BTC", sell, 100000000, 0.006, 2),
+			},
+			want: []PairPnl{
+				{PairId: "LTC/BTC", Realized: 50000, Bought: 50000000, Sold: 100000000, UnmatchedQuantity: 50000000},
+			},
+		},
+		{
+			name: "internal trades",
+			trades: []*pb.Trade{
+				trade("LTC/BTC", both, 100000000, 0.005, 1),
+			},
+			want: []PairPnl{},
+		},
+		{
+			name: "pairs",
+			trades: []*pb.Trade{
+				trade("LTC/BTC", buy, 100000000, 0.005, 1),
+				trade("ETH/BTC", buy, 100000000, 0.03, 2),
+				trade("LTC/BTC", sell, 100000000, 0.004, 3),
+			},
+			want: []PairPnl{
+				{PairId: "ETH/BTC", Bought: 100000000, OpenQuantity: 100000000, OpenCost: 3000000},
+				{PairId: "LTC/BTC", Realized: -100000, Bought: 100000000, Sold: 100000000},
+			},
+		},
+	}
+	for _, test := range tests {
+		got := ComputePnl(test.trades)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestComputePnlKeepsTrades(t *testing.T) {
+	trades := []*pb.Trade{
+		trade("LTC/BTC", pb.OrderSide_SELL, 100000000, 0.006, 2),
+		trade("LTC/BTC", pb.OrderSide_BUY, 100000000, 0.005, 1),
+	}
+	ComputePnl(trades)
+	if trades[0].ExecutedAt != 2 || trades[1].ExecutedAt != 1 {
+		t.Error("ComputePnl reordered its argument")
+	}
+}
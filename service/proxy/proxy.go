@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"github.com/ExchangeUnion/xud-docker-api/config"
 	"github.com/ExchangeUnion/xud-docker-api/service/core"
 	docker "github.com/docker/docker/client"
 )
@@ -10,6 +11,16 @@ type Service struct {
 	*core.SingleContainerService
 }
 
+func init() {
+	core.Register(core.Factory{
+		Name:        "proxy",
+		DisplayName: "Proxy",
+		New: func(ctx core.FactoryContext, c config.ServiceConfig) (core.Service, error) {
+			return New(c.Name, ctx.Services, ctx.ContainerName, ctx.DockerClient), nil
+		},
+	})
+}
+
 func New(
 	name string,
 	services map[string]core.Service,
@@ -21,8 +32,8 @@ func New(
 	}
 }
 
-func (t *Service) GetStatus(ctx context.Context) string {
-	return "Ready"
+func (t *Service) GetStatus(ctx context.Context) core.Status {
+	return core.NewStatus(core.StateReady, "Ready")
 }
 
 func (t *Service) Close() error {
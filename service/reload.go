@@ -0,0 +1,295 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	docker "github.com/docker/docker/client"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	configPollInterval = 5 * time.Second
+)
+
+type ReloadEvent struct {
+	Timestamp int64    `json:"timestamp"`
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+	Updated   []string `json:"updated"`
+	// Failed maps services which could not be created to the reason
+	Failed map[string]string `json:"failed,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// ConfigWatcher polls config.json for changes and applies them to the
+// Manager. Polling is used instead of inotify because xud-docker replaces the
+// file on the host, which is not always visible through the bind mount.
+type ConfigWatcher struct {
+	manager     *Manager
+	modTime     time.Time
+	broadcaster *utils.Broadcaster
+	mutex       *sync.Mutex
+	logger      *logrus.Entry
+}
+
+func NewConfigWatcher(manager *Manager, logger *logrus.Entry) *ConfigWatcher {
+	w := &ConfigWatcher{
+		manager:     manager,
+		broadcaster: utils.NewBroadcaster(),
+		mutex:       &sync.Mutex{},
+		logger:      logger,
+	}
+	if info, err := os.Stat(manager.configFile); err == nil {
+		w.modTime = info.ModTime()
+	}
+	return w
+}
+
+func (t *ConfigWatcher) Watch() {
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		info, err := os.Stat(t.manager.configFile)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().Equal(t.modTime) {
+			continue
+		}
+		t.logger.Infof("Detected changes in %s", t.manager.configFile)
+		// an invalid file is reported once, not on every poll
+		t.modTime = info.ModTime()
+		_, _ = t.Reload()
+	}
+}
+
+// Reload loads config.json and applies it to the Manager. The resulting
+// event (or the error) is emitted to all subscribers.
+func (t *ConfigWatcher) Reload() (*ReloadEvent, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	cfg, err := config.Load(t.manager.configFile)
+	if err != nil {
+		t.logger.Errorf("Failed to reload config: %s", err)
+		t.emit(ReloadEvent{Timestamp: time.Now().Unix(), Error: err.Error()})
+		return nil, err
+	}
+
+	event := t.manager.applyConfig(cfg)
+	t.logger.Infof("Reloaded config: added=%v, removed=%v, updated=%v, failed=%v", event.Added, event.Removed, event.Updated, event.Failed)
+	t.emit(*event)
+	return event, nil
+}
+
+func (t *ConfigWatcher) emit(event ReloadEvent) {
+	if t.broadcaster.Emit(event) > 0 {
+		t.logger.Warn("Dropped reload event for a slow subscriber")
+	}
+}
+
+func (t *ConfigWatcher) Subscribe() (<-chan ReloadEvent, func()) {
+	ch := make(chan ReloadEvent, 10)
+	return ch, t.broadcaster.Add(ch)
+}
+
+func (t *ConfigWatcher) ConfigureRouter(r *gin.RouterGroup) {
+	r.POST("/v1/config/reload", auth.Require(auth.ScopeAdmin), func(c *gin.Context) {
+		event, err := t.Reload()
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.JSON(http.StatusOK, event)
+	})
+
+	r.GET("/v1/config/events", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		events, cancel := t.Subscribe()
+		defer cancel()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case event := <-events:
+				j, _ := json.Marshal(event)
+				c.Writer.Write(j)
+				c.Writer.Write([]byte("\n"))
+				c.Writer.Flush()
+				return true
+			case <-c.Request.Context().Done():
+				return false
+			}
+		})
+	})
+}
+
+// sameServiceConfig reports whether a and b configure a service the same
+// way.
+func sameServiceConfig(a *config.ServiceConfig, b *config.ServiceConfig) bool {
+	if a.Disabled != b.Disabled || a.Mode != b.Mode || a.DisplayName != b.DisplayName || needsRebuild(a, b) {
+		return false
+	}
+	if len(a.Rpc) == 0 || len(b.Rpc) == 0 {
+		return len(a.Rpc) == len(b.Rpc)
+	}
+	var x, y bytes.Buffer
+	if err := json.Compact(&x, a.Rpc); err != nil {
+		return false
+	}
+	if err := json.Compact(&y, b.Rpc); err != nil {
+		return false
+	}
+	return bytes.Equal(x.Bytes(), y.Bytes())
+}
+
+// needsRebuild reports whether changing a service from a to b needs a new
+// implementation or container instead of a reconfiguration in place.
+func needsRebuild(a *config.ServiceConfig, b *config.ServiceConfig) bool {
+	return a.Type != b.Type || a.Container != b.Container
+}
+
+func (t *Manager) reconfigure(s core.Service, c config.ServiceConfig) {
+	if r, ok := s.(core.Reconfigurable); ok {
+		if err := r.Reconfigure(c); err != nil {
+			t.logger.Errorf("Failed to reconfigure service %s: %s", c.Name, err)
+		}
+	}
+	s.SetDisabled(c.Disabled)
+	s.SetMode(c.Mode)
+}
+
+// createService creates the service of c and tells the OnServiceCreated
+// callbacks about it. The caller must hold t.mutex.
+func (t *Manager) createService(c config.ServiceConfig, dockerClient *docker.Client) (core.Service, error) {
+	s, err := newService(t.network, c, t.serviceMap, dockerClient)
+	if err != nil {
+		return nil, err
+	}
+	s.SetDisabled(c.Disabled)
+	s.SetMode(c.Mode)
+	for _, f := range t.createdListeners {
+		f(s)
+	}
+	return s, nil
+}
+
+// applyConfig diffs cfg against the running services. Changed services are
+// reconfigured in place, or created again if their type or container
+// changed. New ones are created and removed ones are closed.
+func (t *Manager) applyConfig(cfg *config.Config) *ReloadEvent {
+	dockerClient := t.factory.GetSharedInstance()
+
+	t.mutex.Lock()
+
+	event := &ReloadEvent{
+		Timestamp: time.Now().Unix(),
+		Added:     []string{},
+		Removed:   []string{},
+		Updated:   []string{},
+	}
+	fail := func(name string, err error) {
+		t.logger.Errorf("Failed to create service %s: %s", name, err)
+		if event.Failed == nil {
+			event.Failed = map[string]string{}
+		}
+		event.Failed[name] = err.Error()
+	}
+
+	var services []core.Service
+	var closing []core.Service
+	seen := map[string]bool{}
+
+	for _, c := range cfg.Services {
+		seen[c.Name] = true
+
+		old := t.config.GetService(c.Name)
+		s, ok := t.serviceMap[c.Name]
+		rebuilt := false
+		if ok && old != nil && needsRebuild(old, &c) {
+			closing = append(closing, s)
+			delete(t.serviceMap, c.Name)
+			delete(t.listeners, s.GetContainerName())
+			ok = false
+			rebuilt = true
+		} else if ok {
+			if old == nil || !sameServiceConfig(old, &c) {
+				t.reconfigure(s, c)
+				event.Updated = append(event.Updated, c.Name)
+			}
+		}
+
+		if !ok {
+			var err error
+			s, err = t.createService(c, dockerClient)
+			if err != nil {
+				fail(c.Name, err)
+				if rebuilt {
+					event.Removed = append(event.Removed, c.Name)
+				}
+				continue
+			}
+			if rebuilt {
+				event.Updated = append(event.Updated, c.Name)
+			} else {
+				event.Added = append(event.Added, c.Name)
+			}
+		}
+
+		services = append(services, s)
+		t.serviceMap[c.Name] = s
+		t.listeners[s.GetContainerName()] = s
+	}
+
+	for _, s := range t.services {
+		name := s.GetName()
+		if name == config.ProxyService || seen[name] {
+			continue
+		}
+		closing = append(closing, s)
+		delete(t.serviceMap, name)
+		delete(t.listeners, s.GetContainerName())
+		event.Removed = append(event.Removed, name)
+	}
+
+	if proxy, ok := t.serviceMap[config.ProxyService]; ok {
+		services = append(services, proxy)
+	}
+
+	t.services = services
+	t.config = cfg
+	t.failed = map[string]string{}
+	for name, reason := range event.Failed {
+		t.failed[name] = reason
+	}
+
+	if t.routed && (len(event.Added) > 0 || len(event.Removed) > 0 || len(closing) > 0) {
+		t.rebuildServiceRouter()
+	}
+
+	t.mutex.Unlock()
+
+	for _, s := range closing {
+		if err := s.Close(); err != nil {
+			t.logger.Errorf("Failed to close service %s: %s", s.GetName(), err)
+		}
+	}
+
+	return event
+}
+
+func (t *Manager) ReloadConfig() (*ReloadEvent, error) {
+	return t.watcher.Reload()
+}
+
+func (t *Manager) SubscribeConfigReload() (<-chan ReloadEvent, func()) {
+	return t.watcher.Subscribe()
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"encoding/json"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	"testing"
+)
+
+func TestSameServiceConfig(t *testing.T) {
+	base := config.ServiceConfig{Name: "xud", Mode: "native", Rpc: json.RawMessage(`{"host": "xud", "port": 8886}`)}
+
+	tests := []struct {
+		name    string
+		change  func(c *config.ServiceConfig)
+		same    bool
+		rebuild bool
+	}{
+		{name: "unchanged", change: func(c *config.ServiceConfig) {}, same: true},
+		{
+			name:   "rpc formatting",
+			change: func(c *config.ServiceConfig) { c.Rpc = json.RawMessage("{\n  \"host\": \"xud\",\n  \"port\": 8886\n}") },
+			same:   true,
+		},
+		{name: "rpc", change: func(c *config.ServiceConfig) { c.Rpc = json.RawMessage(`{"host": "xud", "port": 18886}`) }},
+		{name: "invalid rpc", change: func(c *config.ServiceConfig) { c.Rpc = json.RawMessage(`{`) }},
+		{name: "disabled", change: func(c *config.ServiceConfig) { c.Disabled = true }},
+		{name: "mode", change: func(c *config.ServiceConfig) { c.Mode = "external" }},
+		{name: "display name", change: func(c *config.ServiceConfig) { c.DisplayName = "XUD" }},
+		{name: "type", change: func(c *config.ServiceConfig) { c.Type = config.DockerOnly }, rebuild: true},
+		{name: "container", change: func(c *config.ServiceConfig) { c.Container = "mainnet_xud_2" }, rebuild: true},
+	}
+	for _, test := range tests {
+		c := base
+		test.change(&c)
+		if same := sameServiceConfig(&base, &c); same != test.same {
+			t.Errorf("%s: sameServiceConfig = %v, want %v", test.name, same, test.same)
+		}
+		if rebuild := needsRebuild(&base, &c); rebuild != test.rebuild {
+			t.Errorf("%s: needsRebuild = %v, want %v", test.name, rebuild, test.rebuild)
+		}
+	}
+}
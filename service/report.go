@@ -0,0 +1,307 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/build"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	"github.com/ExchangeUnion/xud-docker-api/service/lnd"
+	"github.com/ExchangeUnion/xud-docker-api/service/xud"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultReportLines = 1000
+	maxReportLines     = 100000
+	redacted           = "[REDACTED]"
+)
+
+var (
+	// keys of JSON objects whose values are removed entirely
+	sensitiveKeyPattern = regexp.MustCompile(`(?i)(pass(?:word|wd|phrase)?\b|secret|token|macaroon|mnemonic|seed|xpub|privkey|private_?key|api_?key)`)
+
+	textRedactions = []struct {
+		pattern     *regexp.Regexp
+		replacement string
+	}{
+		// KEY=value, --rpcpassword=value, "password": "value" etc.
+		{regexp.MustCompile(`(?i)([\w.-]*(?:pass(?:word|wd|phrase)?\b|secret|token|macaroon|mnemonic|xpub|privkey|private_?key|api_?key)[\w.-]*"?\s*[=:]\s*)("[^"]*"|\S+)`), "${1}" + redacted},
+		// the rest of a line mentioning a mnemonic or seed phrase
+		{regexp.MustCompile(`(?i)((?:mnemonic|seed phrase|seed words)[^:=]*[:=]).*`), "${1} " + redacted},
+		// extended public and private keys
+		{regexp.MustCompile(`\b[xtyzuvYZUV](?:pub|prv)[1-9A-HJ-NP-Za-km-z]{100,}\b`), redacted},
+		// hex-encoded macaroons
+		{regexp.MustCompile(`\b0201036c6e64[0-9a-fA-F]+\b`), redacted},
+	}
+)
+
+// RedactText removes passwords, macaroons, mnemonics and extended keys from
+// free text such as log lines.
+func RedactText(text string) string {
+	for _, r := range textRedactions {
+		text = r.pattern.ReplaceAllString(text, r.replacement)
+	}
+	return text
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if sensitiveKeyPattern.MatchString(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+		return v
+	case string:
+		return RedactText(v)
+	default:
+		return v
+	}
+}
+
+// RedactJson redacts the values of sensitive keys and all strings of a JSON
+// document and returns it indented.
+func RedactJson(data []byte) ([]byte, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(redactValue(value), "", "  ")
+}
+
+// reportArchive writes the files of a report as a tar.gz or zip archive.
+type reportArchive struct {
+	tar  *tar.Writer
+	gzip *gzip.Writer
+	zip  *zip.Writer
+}
+
+func newReportArchive(w io.Writer, format string) *reportArchive {
+	if format == "zip" {
+		return &reportArchive{zip: zip.NewWriter(w)}
+	}
+	gw := gzip.NewWriter(w)
+	return &reportArchive{tar: tar.NewWriter(gw), gzip: gw}
+}
+
+func (t *reportArchive) Add(name string, data []byte) error {
+	if t.zip != nil {
+		w, err := t.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	err := t.tar.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = t.tar.Write(data)
+	return err
+}
+
+func (t *reportArchive) Close() error {
+	if t.zip != nil {
+		return t.zip.Close()
+	}
+	if err := t.tar.Close(); err != nil {
+		return err
+	}
+	return t.gzip.Close()
+}
+
+// tailFile returns the last n lines of the file at path.
+func tailFile(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func redactLines(lines []string) []byte {
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.WriteString(RedactText(line))
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+func marshalProto(msg proto.Message) ([]byte, error) {
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	s, err := m.MarshalToString(msg)
+	if err != nil {
+		return nil, err
+	}
+	return RedactJson([]byte(s))
+}
+
+// WriteReport collects a diagnostic bundle: the last lines lines of every
+// service's logs, the launcher log, statuses, redacted docker inspect output,
+// xud/lnd GetInfo, the proxy version and the sanitized config.json. Items
+// which can't be collected are listed in errors.txt.
+func (t *Manager) WriteReport(w io.Writer, format string, lines int) error {
+	archive := newReportArchive(w, format)
+	var errs []string
+	addJson := func(name string, value interface{}) {
+		j, err := json.Marshal(value)
+		if err == nil {
+			j, err = RedactJson(j)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+			return
+		}
+		if err := archive.Add(name, j); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+	add := func(name string, data []byte) {
+		if err := archive.Add(name, data); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+
+	addJson("version.json", map[string]string{
+		"version":   build.Version,
+		"gitCommit": build.GitCommit,
+		"timestamp": build.Timestamp,
+	})
+
+	var status []ServiceStatusV2
+	current := t.GetStatus()
+	for _, s := range t.getServices() {
+		if st, ok := current[s.GetName()]; ok {
+			status = append(status, ServiceStatusV2{Service: s.GetName(), Status: st})
+		}
+	}
+	addJson("status.json", status)
+
+	if data, err := ioutil.ReadFile(t.configFile); err != nil {
+		errs = append(errs, fmt.Sprintf("config.json: %s", err))
+	} else if data, err = RedactJson(data); err != nil {
+		errs = append(errs, fmt.Sprintf("config.json: %s", err))
+	} else {
+		add("config.json", data)
+	}
+
+	if logs, err := tailFile(t.LauncherAgent.logfile, lines); err != nil {
+		errs = append(errs, fmt.Sprintf("launcher.log: %s", err))
+	} else {
+		add("launcher.log", redactLines(logs))
+	}
+
+	for _, s := range t.getServices() {
+		name := s.GetName()
+
+		if cs, ok := s.(containerService); ok {
+			if c, err := cs.GetContainer(); err != nil {
+				errs = append(errs, fmt.Sprintf("inspect/%s.json: %s", name, err))
+			} else {
+				addJson("inspect/"+name+".json", c)
+			}
+		}
+
+		if logs, err := s.GetLogs("", strconv.Itoa(lines)); err != nil {
+			errs = append(errs, fmt.Sprintf("logs/%s.log: %s", name, err))
+		} else {
+			add("logs/"+name+".log", redactLines(logs))
+		}
+
+		var info proto.Message
+		var err error
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		switch svc := s.(type) {
+		case *xud.Service:
+			info, err = svc.GetInfo(ctx)
+		case *lnd.Service:
+			info, err = svc.GetInfo(ctx)
+		}
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("info/%s.json: %s", name, err))
+		} else if info != nil {
+			if j, err := marshalProto(info); err != nil {
+				errs = append(errs, fmt.Sprintf("info/%s.json: %s", name, err))
+			} else {
+				add("info/"+name+".json", j)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		add("errors.txt", []byte(strings.Join(errs, "\n")+"\n"))
+	}
+
+	return archive.Close()
+}
+
+func (t *Manager) configureReportRouter(r *gin.RouterGroup) {
+	r.GET("/v1/report", auth.Require(auth.ScopeAdmin), func(c *gin.Context) {
+		format := c.DefaultQuery("format", "tar.gz")
+		if format != "tar.gz" && format != "zip" {
+			utils.JsonError(c, "invalid format: "+format, http.StatusBadRequest)
+			return
+		}
+		lines := defaultReportLines
+		if value := c.Query("lines"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 || n > maxReportLines {
+				utils.JsonError(c, "invalid lines: "+value, http.StatusBadRequest)
+				return
+			}
+			lines = n
+		}
+
+		filename := fmt.Sprintf("%s-report-%s.%s", t.network, time.Now().UTC().Format("20060102-150405"), format)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+		if format == "zip" {
+			c.Header("Content-Type", "application/zip")
+		} else {
+			c.Header("Content-Type", "application/gzip")
+		}
+		c.Status(http.StatusOK)
+		if err := t.WriteReport(c.Writer, format, lines); err != nil {
+			t.logger.Errorf("Failed to write report: %s", err)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRedactText(t *testing.T) {
+	xpub := "xpub6CUGRUonZSQ4TWtTMmzXdrXDtypWKiKrhko4egpiMZbpiaQL2jkwSB1icqYh2cfDfVxdx4df189oLKnC5fSwqPfgyP3hooxujYzAu3fDVmz"
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "plain", text: "Synced to block 123", want: "Synced to block 123"},
+		{name: "env", text: "RPC_PASSWORD=hunter2 RPC_USER=xu", want: "RPC_PASSWORD=[REDACTED] RPC_USER=xu"},
+		{name: "flag", text: "bitcoind --rpcpassword=hunter2 -rpcuser=xu", want: "bitcoind --rpcpassword=[REDACTED] -rpcuser=xu"},
+		{name: "json", text: `{"password": "hunter2", "user": "xu"}`, want: `{"password": [REDACTED], "user": "xu"}`},
+		{name: "macaroon path", text: "macaroon: /root/.lnd/admin.macaroon", want: "macaroon: [REDACTED]"},
+		{name: "api key", text: "api_key=abc123", want: "api_key=[REDACTED]"},
+		{name: "mnemonic", text: "Your mnemonic is: abandon ability able about", want: "Your mnemonic is: [REDACTED]"},
+		{name: "seed phrase", text: "seed phrase = abandon ability able", want: "seed phrase = [REDACTED]"},
+		{name: "xpub", text: "derived from " + xpub + " at m/0", want: "derived from [REDACTED] at m/0"},
+		{name: "hex macaroon", text: "mac 0201036c6e6402f801030a10 end", want: "mac [REDACTED] end"},
+		{name: "passthrough", text: "passed 3 checks", want: "passed 3 checks"},
+	}
+	for _, test := range tests {
+		if got := RedactText(test.text); got != test.want {
+			t.Errorf("%s: RedactText(%q) = %q, want %q", test.name, test.text, got, test.want)
+		}
+	}
+}
+
+func TestRedactJson(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "sensitive keys",
+			data: `{"name": "xud", "password": "hunter2", "Macaroon": {"path": "/x"}, "seed": ["a", "b"]}`,
+			want: `{"name": "xud", "password": "[REDACTED]", "Macaroon": "[REDACTED]", "seed": "[REDACTED]"}`,
+		},
+		{
+			name: "nested",
+			data: `{"services": [{"name": "bitcoind", "rpc": {"port": 18443, "rpcpasswd": "x"}}]}`,
+			want: `{"services": [{"name": "bitcoind", "rpc": {"port": 18443, "rpcpasswd": "[REDACTED]"}}]}`,
+		},
+		{
+			name: "strings",
+			data: `{"cmd": ["bitcoind", "--rpcpassword=hunter2"], "port": 8886, "tls": true}`,
+			want: `{"cmd": ["bitcoind", "--rpcpassword=[REDACTED]"], "port": 8886, "tls": true}`,
+		},
+		{name: "array", data: `[{"token": "abc"}, "ok", null]`, want: `[{"token": "[REDACTED]"}, "ok", null]`},
+	}
+	for _, test := range tests {
+		got, err := RedactJson([]byte(test.data))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		var gotValue, wantValue interface{}
+		if err := json.Unmarshal(got, &gotValue); err != nil {
+			t.Errorf("%s: invalid JSON %s: %s", test.name, got, err)
+			continue
+		}
+		if err := json.Unmarshal([]byte(test.want), &wantValue); err != nil {
+			t.Fatalf("%s: invalid test JSON: %s", test.name, err)
+		}
+		if !reflect.DeepEqual(gotValue, wantValue) {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+
+	if _, err := RedactJson([]byte(`{"password":`)); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
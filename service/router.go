@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/logging"
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// The routes of services are bound to the service instances, which are
+// recreated when config.json changes. gin doesn't allow changing the routes
+// of an engine that is already serving, so they live on a separate engine
+// which is rebuilt on every reload and swapped in atomically. The main engine
+// hands unmatched requests over to it in ServeServiceRoutes.
+type serviceRouter struct {
+	engine *gin.Engine
+	routes []Route
+}
+
+type claimsContextKey struct{}
+
+// registerServiceRoutes registers the routes of s on api and returns the
+// routes that were added to engine.
+func registerServiceRoutes(engine *gin.Engine, api *gin.RouterGroup, s core.Service) []Route {
+	existing := map[string]bool{}
+	for _, r := range engine.Routes() {
+		existing[r.Method+" "+r.Path] = true
+	}
+
+	s.ConfigureRouter(api)
+
+	routes := []Route{}
+	for _, r := range engine.Routes() {
+		if !existing[r.Method+" "+r.Path] {
+			routes = append(routes, Route{Method: r.Method, Path: r.Path})
+		}
+	}
+	return routes
+}
+
+// rebuildServiceRouter builds a new engine with the routes of the current
+// services and swaps it in. The routes of removed services are kept in
+// t.routes so that they can be answered with 503. The caller must hold
+// t.mutex.
+func (t *Manager) rebuildServiceRouter() {
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		if claims, ok := c.Request.Context().Value(claimsContextKey{}).(*auth.Claims); ok && claims != nil {
+			c.Set(auth.ClaimsKey, claims)
+		}
+		c.Next()
+	})
+	api := engine.Group("/api")
+
+	router := &serviceRouter{engine: engine}
+	for _, s := range t.services {
+		routes := registerServiceRoutes(engine, api, s)
+		t.routes[s.GetName()] = routes
+		router.routes = append(router.routes, routes...)
+	}
+	t.serviceRouter.Store(router)
+}
+
+// ServeServiceRoutes is meant to be the first NoRoute handler of the main
+// engine. It serves the routes of the services, answers 503 for the routes
+// of services which were removed from config.json and passes everything else
+// on to the next handler.
+func (t *Manager) ServeServiceRoutes(c *gin.Context) {
+	method, path := c.Request.Method, c.Request.URL.Path
+
+	router, ok := t.serviceRouter.Load().(*serviceRouter)
+	if ok {
+		if route, ok := matchRoutes(router.routes, method, path); ok {
+			c.Set(logging.RouteKey, route.Path)
+			ctx := context.WithValue(c.Request.Context(), claimsContextKey{}, auth.GetClaims(c))
+			router.engine.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+			c.Abort()
+			return
+		}
+	}
+
+	t.mutex.RLock()
+	var absent string
+	for name, routes := range t.routes {
+		if _, present := t.serviceMap[name]; present {
+			continue
+		}
+		if _, ok := matchRoutes(routes, method, path); ok {
+			absent = name
+			break
+		}
+	}
+	t.mutex.RUnlock()
+	if absent != "" {
+		utils.JsonError(c, fmt.Sprintf("service %s is not available", absent), http.StatusServiceUnavailable)
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+func matchRoutes(routes []Route, method string, path string) (Route, bool) {
+	for _, r := range routes {
+		if r.Method == method && matchRoute(r.Path, path) {
+			return r, true
+		}
+	}
+	return Route{}, false
+}
+
+// matchRoute reports whether path matches a gin route pattern with :param
+// and *wildcard segments.
+func matchRoute(pattern string, path string) bool {
+	patternParts := strings.Split(pattern, "/")
+	pathParts := strings.Split(path, "/")
+	for i, p := range patternParts {
+		if strings.HasPrefix(p, "*") {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if strings.HasPrefix(p, ":") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if p != pathParts[i] {
+			return false
+		}
+	}
+	return len(patternParts) == len(pathParts)
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestMatchRoute(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/api/v1/xud/getinfo", "/api/v1/xud/getinfo", true},
+		{"/api/v1/xud/getinfo", "/api/v1/xud/getinfo/x", false},
+		{"/api/v1/xud/getinfo", "/api/v1/xud", false},
+		{"/api/v1/xud/getbalance/:currency", "/api/v1/xud/getbalance/BTC", true},
+		{"/api/v1/xud/getbalance/:currency", "/api/v1/xud/getbalance/", false},
+		{"/api/v1/xud/getbalance/:currency", "/api/v1/xud/getbalance", false},
+		{"/api/v1/lnd/:service/*path", "/api/v1/lnd/lndbtc/a/b", true},
+		// gin redirects this to the trailing slash
+		{"/api/v1/lnd/:service/*path", "/api/v1/lnd/lndbtc", true},
+		{"/api/v1/boltz/:currency", "/api/v1/xud/BTC", false},
+	}
+	for _, test := range tests {
+		if got := matchRoute(test.pattern, test.path); got != test.want {
+			t.Errorf("matchRoute(%q, %q) = %v, want %v", test.pattern, test.path, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	networkDataDir = "/root/network/data"
+	// walking a blockchain directory is expensive, so sizes are cached
+	dataDirSizeTtl = 5 * time.Minute
+)
+
+// ServiceStats are the container stats of a service and the size of its data
+// directory. DataDirSize is null until the directory has been measured,
+// DataDirMeasuredAt is the Unix timestamp of the measurement.
+type ServiceStats struct {
+	Service           string               `json:"service"`
+	Stats             *core.ContainerStats `json:"stats"`
+	DataDirSize       *int64               `json:"dataDirSize"`
+	DataDirMeasuredAt int64                `json:"dataDirMeasuredAt,omitempty"`
+	Error             string               `json:"error,omitempty"`
+}
+
+type AggregateStats struct {
+	Services []ServiceStats `json:"services"`
+	Total    ServiceStats   `json:"total"`
+}
+
+type dirSize struct {
+	size      int64
+	updatedAt time.Time
+}
+
+// dataDirSizes walks the data directories in the background, requests are
+// only ever served the last computed size.
+type dataDirSizes struct {
+	cache   map[string]dirSize
+	walking map[string]bool
+	mutex   *sync.Mutex
+}
+
+func newDataDirSizes() *dataDirSizes {
+	return &dataDirSizes{
+		cache:   map[string]dirSize{},
+		walking: map[string]bool{},
+		mutex:   &sync.Mutex{},
+	}
+}
+
+func walkDirSize(dir string) int64 {
+	var size int64
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// skip unreadable entries instead of failing the whole walk
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// Get returns the last computed size in bytes of the data directory of
+// service. The flag is false until the first walk has finished. A stale size
+// is refreshed in the background, at most one walk per directory at a time.
+func (t *dataDirSizes) Get(service string) (dirSize, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	cached, ok := t.cache[service]
+	if (!ok || time.Since(cached.updatedAt) >= dataDirSizeTtl) && !t.walking[service] {
+		t.walking[service] = true
+		go t.refresh(service)
+	}
+	return cached, ok
+}
+
+// newServiceStats returns the stats of service with the size of its data
+// directory if it has been measured.
+func (t *Manager) newServiceStats(service string, stats *core.ContainerStats) ServiceStats {
+	result := ServiceStats{Service: service, Stats: stats}
+	if d, ok := t.dataDirSizes.Get(service); ok {
+		size := d.size
+		result.DataDirSize = &size
+		result.DataDirMeasuredAt = d.updatedAt.Unix()
+	}
+	return result
+}
+
+func (t *dataDirSizes) refresh(service string) {
+	size := walkDirSize(filepath.Join(networkDataDir, service))
+
+	t.mutex.Lock()
+	t.cache[service] = dirSize{size: size, updatedAt: time.Now()}
+	delete(t.walking, service)
+	t.mutex.Unlock()
+}
+
+func (t *Manager) getStatsProvider(name string) (core.StatsProvider, error) {
+	s, err := t.GetService(name)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := s.(core.StatsProvider)
+	if !ok {
+		return nil, errNoContainer(name)
+	}
+	return p, nil
+}
+
+func (t *Manager) GetServiceStats(ctx context.Context, name string) (*ServiceStats, error) {
+	p, err := t.getStatsProvider(name)
+	if err != nil {
+		return nil, err
+	}
+	result := t.newServiceStats(name, nil)
+	stats, err := p.GetStats(ctx)
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Stats = stats
+	}
+	return &result, nil
+}
+
+// GetAggregateStats collects the stats of all services concurrently and sums
+// them up. Services without a running container only contribute their data
+// directory. The total data directory size is null until all directories
+// have been measured and dated by the oldest measurement.
+func (t *Manager) GetAggregateStats(ctx context.Context) *AggregateStats {
+	services := t.getServices()
+	results := make([]ServiceStats, len(services))
+
+	var wg sync.WaitGroup
+	for i, svc := range services {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			stats, err := t.GetServiceStats(ctx, name)
+			if err != nil {
+				results[i] = ServiceStats{Service: name, Error: err.Error()}
+				return
+			}
+			results[i] = *stats
+		}(i, svc.GetName())
+	}
+	wg.Wait()
+
+	total := ServiceStats{Service: "total", Stats: &core.ContainerStats{Timestamp: time.Now().Unix()}}
+	var dataDirSize int64
+	measured := true
+	for _, r := range results {
+		if r.DataDirSize == nil {
+			measured = false
+		} else {
+			dataDirSize += *r.DataDirSize
+			if total.DataDirMeasuredAt == 0 || r.DataDirMeasuredAt < total.DataDirMeasuredAt {
+				total.DataDirMeasuredAt = r.DataDirMeasuredAt
+			}
+		}
+		if r.Stats == nil {
+			continue
+		}
+		total.Stats.CpuPercent += r.Stats.CpuPercent
+		total.Stats.MemoryUsage += r.Stats.MemoryUsage
+		total.Stats.NetworkRx += r.Stats.NetworkRx
+		total.Stats.NetworkTx += r.Stats.NetworkTx
+		total.Stats.BlockRead += r.Stats.BlockRead
+		total.Stats.BlockWrite += r.Stats.BlockWrite
+		// all containers share the memory of the host
+		if r.Stats.MemoryLimit > total.Stats.MemoryLimit {
+			total.Stats.MemoryLimit = r.Stats.MemoryLimit
+		}
+	}
+	if measured {
+		total.DataDirSize = &dataDirSize
+	} else {
+		total.DataDirMeasuredAt = 0
+	}
+	if total.Stats.MemoryLimit > 0 {
+		total.Stats.MemoryPercent = float64(total.Stats.MemoryUsage) / float64(total.Stats.MemoryLimit) * 100.0
+	}
+
+	return &AggregateStats{Services: results, Total: total}
+}
+
+func (t *Manager) configureStatsRouter(r *gin.RouterGroup) {
+	r.GET("/v1/stats", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		c.JSON(http.StatusOK, t.GetAggregateStats(ctx))
+	})
+
+	r.GET("/v1/services/:service/stats", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		stats, err := t.GetServiceStats(ctx, c.Param("service"))
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusNotFound)
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+	})
+
+	r.GET("/v1/services/:service/stats/stream", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		service := c.Param("service")
+		p, err := t.getStatsProvider(service)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusNotFound)
+			return
+		}
+		stats, err := p.StreamStats(c.Request.Context())
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		c.Stream(func(w io.Writer) bool {
+			s, ok := <-stats
+			if !ok {
+				return false
+			}
+			j, _ := json.Marshal(t.newServiceStats(service, &s))
+			c.Writer.Write(j)
+			c.Writer.Write([]byte("\n"))
+			c.Writer.Flush()
+			return true
+		})
+	})
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/service/xud"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	swapsFile      = "swaps.db"
+	swapsBucket    = "swaps"
+	swapsRetention = 90 * 24 * time.Hour
+
+	defaultSwapsLimit = 50
+	maxSwapsLimit     = 1000
+
+	swapServiceRetryInterval = 10 * time.Second
+)
+
+// SwapHistory persists the swap events of xud in a bbolt database. Keys are
+// the big-endian UnixNano timestamp of the event followed by a sequence
+// number, so a cursor walks the events in chronological order.
+type SwapHistory struct {
+	db          *bolt.DB
+	broadcaster *utils.Broadcaster
+	logger      *logrus.Entry
+}
+
+type SwapQuery struct {
+	Type       string
+	PairId     string
+	Role       string
+	PeerPubKey string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+	Offset     int
+	// CountTotal makes Query walk all events to count the matching ones
+	CountTotal bool
+}
+
+// SwapPage is a page of swap events. More tells if there are matching
+// events after the page, Total is only set if it was requested.
+type SwapPage struct {
+	Total *int            `json:"total,omitempty"`
+	More  bool            `json:"more"`
+	Swaps []xud.SwapEvent `json:"swaps"`
+}
+
+func NewSwapHistory(dataDir string, logger *logrus.Entry) (*SwapHistory, error) {
+	db, err := bolt.Open(filepath.Join(dataDir, swapsFile), 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open swap history: %s", err)
+	}
+	return &SwapHistory{
+		db:          db,
+		broadcaster: utils.NewBroadcaster(),
+		logger:      logger,
+	}, nil
+}
+
+// Record stores event and pushes it to the subscribers. Events older than
+// swapsRetention are pruned.
+func (t *SwapHistory) Record(event xud.SwapEvent) error {
+	err := t.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(swapsBucket))
+		if err != nil {
+			return err
+		}
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 16)
+		binary.BigEndian.PutUint64(key, uint64(event.Timestamp)*uint64(time.Millisecond))
+		binary.BigEndian.PutUint64(key[8:], seq)
+		j, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(key, j); err != nil {
+			return err
+		}
+		return pruneBefore(b, timeKey(time.Now().Add(-swapsRetention)))
+	})
+	t.emit(event)
+	return err
+}
+
+func (q SwapQuery) match(e xud.SwapEvent) bool {
+	if q.Type != "" && e.Type != q.Type {
+		return false
+	}
+	if q.PairId != "" && e.PairId != q.PairId {
+		return false
+	}
+	if q.Role != "" && e.Role != q.Role {
+		return false
+	}
+	if q.PeerPubKey != "" && e.PeerPubKey != q.PeerPubKey {
+		return false
+	}
+	return true
+}
+
+// Query returns the events matching q, newest first. It stops at the first
+// match after the page unless q.CountTotal is set.
+func (t *SwapHistory) Query(q SwapQuery) (*SwapPage, error) {
+	result := &SwapPage{Swaps: []xud.SwapEvent{}}
+	matched := 0
+	err := t.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(swapsBucket))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		var k, v []byte
+		if q.Until.IsZero() {
+			k, v = c.Last()
+		} else {
+			// the first key after until, then step back
+			k, v = c.Seek(timeKey(q.Until.Add(time.Nanosecond)))
+			if k == nil {
+				k, v = c.Last()
+			} else {
+				k, v = c.Prev()
+			}
+		}
+		var since []byte
+		if !q.Since.IsZero() {
+			since = timeKey(q.Since)
+		}
+		for ; k != nil && string(k[:8]) >= string(since); k, v = c.Prev() {
+			var e xud.SwapEvent
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			if !q.match(e) {
+				continue
+			}
+			if matched >= q.Offset {
+				if len(result.Swaps) < q.Limit {
+					result.Swaps = append(result.Swaps, e)
+				} else {
+					result.More = true
+					if !q.CountTotal {
+						break
+					}
+				}
+			}
+			matched++
+		}
+		return nil
+	})
+	if q.CountTotal {
+		result.Total = &matched
+	}
+	return result, err
+}
+
+func (t *SwapHistory) emit(event xud.SwapEvent) {
+	if t.broadcaster.Emit(event) > 0 {
+		t.logger.Warn("Dropped swap event for a slow subscriber")
+	}
+}
+
+func (t *SwapHistory) Subscribe() (<-chan xud.SwapEvent, func()) {
+	ch := make(chan xud.SwapEvent, 100)
+	return ch, t.broadcaster.Add(ch)
+}
+
+func (t *SwapHistory) Close() error {
+	return t.db.Close()
+}
+
+// recordSwaps stores the swap events of the xud service. It subscribes again
+// whenever the service is recreated.
+func (t *Manager) recordSwaps() {
+	for {
+		if s, err := t.GetService("xud"); err == nil {
+			if x, ok := s.(*xud.Service); ok {
+				events, _ := x.SubscribeSwapEvents()
+				for event := range events {
+					if err := t.swaps.Record(event); err != nil {
+						t.logger.Errorf("Failed to record swap of order %s: %s", event.OrderId, err)
+					}
+				}
+			}
+		}
+		time.Sleep(swapServiceRetryInterval)
+	}
+}
+
+func (t *Manager) SubscribeSwaps() (<-chan xud.SwapEvent, func()) {
+	return t.swaps.Subscribe()
+}
+
+func parseSwapQuery(c *gin.Context) (*SwapQuery, error) {
+	q := &SwapQuery{
+		Type:       c.Query("type"),
+		PairId:     c.Query("pairId"),
+		Role:       c.Query("role"),
+		PeerPubKey: c.Query("peerPubKey"),
+		Limit:      defaultSwapsLimit,
+		CountTotal: c.Query("total") == "true",
+	}
+	switch q.Type {
+	case "", xud.SwapAccepted, xud.SwapSuccess, xud.SwapFailure:
+	default:
+		return nil, fmt.Errorf("invalid type: %s", q.Type)
+	}
+	var err error
+	if value := c.Query("since"); value != "" {
+		if q.Since, err = parseSince(value); err != nil {
+			return nil, err
+		}
+	}
+	if value := c.Query("until"); value != "" {
+		if q.Until, err = parseSince(value); err != nil {
+			return nil, fmt.Errorf("invalid until: %s", value)
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		q.Limit, err = strconv.Atoi(value)
+		if err != nil || q.Limit <= 0 || q.Limit > maxSwapsLimit {
+			return nil, fmt.Errorf("invalid limit: %s", value)
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		q.Offset, err = strconv.Atoi(value)
+		if err != nil || q.Offset < 0 {
+			return nil, fmt.Errorf("invalid offset: %s", value)
+		}
+	}
+	return q, nil
+}
+
+func (t *Manager) configureSwapsRouter(r *gin.RouterGroup) {
+	r.GET("/v1/xud/swaps", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		q, err := parseSwapQuery(c)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		page, err := t.swaps.Query(*q)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	})
+
+	r.GET("/v1/xud/swaps/stream", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		events, cancel := t.swaps.Subscribe()
+		defer cancel()
+
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+		c.Writer.Flush()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case event := <-events:
+				c.SSEvent("swap", event)
+				return true
+			case <-c.Request.Context().Done():
+				return false
+			}
+		})
+	})
+}
@@ -2,6 +2,7 @@ package webui
 
 import (
 	"context"
+	"github.com/ExchangeUnion/xud-docker-api/config"
 	"github.com/ExchangeUnion/xud-docker-api/service/core"
 	docker "github.com/docker/docker/client"
 )
@@ -10,6 +11,17 @@ type Service struct {
 	*core.SingleContainerService
 }
 
+func init() {
+	core.Register(core.Factory{
+		Name:         "webui",
+		DisplayName:  "Web UI",
+		Dependencies: []string{"xud"},
+		New: func(ctx core.FactoryContext, c config.ServiceConfig) (core.Service, error) {
+			return New(c.Name, ctx.Services, ctx.ContainerName, ctx.DockerClient), nil
+		},
+	})
+}
+
 func New(
 	name string,
 	services map[string]core.Service,
@@ -21,17 +33,14 @@ func New(
 	}
 }
 
-func (t *Service) GetStatus(ctx context.Context) string {
+func (t *Service) GetStatus(ctx context.Context) core.Status {
 	status := t.SingleContainerService.GetStatus(ctx)
-	if status == "Disabled" {
-		return status
-	}
-	if status != "Container running" {
+	if !status.IsReady() {
 		return status
 	}
 
 	// container is running
-	return "Ready"
+	return core.NewStatus(core.StateReady, "Ready")
 }
 
 func (t *Service) Close() error {
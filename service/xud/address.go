@@ -0,0 +1,180 @@
+package xud
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// chainParams are the address prefixes of a UTXO chain on one network.
+type chainParams struct {
+	bech32Hrp string
+	// version bytes of base58check encoded P2PKH and P2SH addresses
+	versions []byte
+}
+
+var (
+	// the prefixes of bitcoind, litecoind and btcd/ltcd (simnet) per network
+	utxoChains = map[string]map[string]chainParams{
+		"BTC": {
+			"mainnet": {bech32Hrp: "bc", versions: []byte{0x00, 0x05}},
+			"testnet": {bech32Hrp: "tb", versions: []byte{0x6f, 0xc4}},
+			"simnet":  {bech32Hrp: "sb", versions: []byte{0x3f, 0x7b}},
+		},
+		"LTC": {
+			"mainnet": {bech32Hrp: "ltc", versions: []byte{0x30, 0x32, 0x05}},
+			"testnet": {bech32Hrp: "tltc", versions: []byte{0x6f, 0x3a, 0xc4}},
+			"simnet":  {bech32Hrp: "sltc", versions: []byte{0x3f, 0x7b}},
+		},
+	}
+
+	ethAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+)
+
+const (
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	bech32Charset  = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// ValidateAddress checks that address can receive currency on network. BTC
+// and LTC addresses must be base58check or segwit addresses of the network.
+// Every other currency is an Ethereum token, whose addresses are the same on
+// all networks.
+func ValidateAddress(network string, currency string, address string) error {
+	chain, ok := utxoChains[strings.ToUpper(currency)]
+	if !ok {
+		if !ethAddressPattern.MatchString(address) {
+			return fmt.Errorf("invalid %s address: %s", currency, address)
+		}
+		return nil
+	}
+	params, ok := chain[network]
+	if !ok {
+		return fmt.Errorf("unsupported network: %s", network)
+	}
+	for other, p := range chain {
+		if other != network && strings.HasPrefix(strings.ToLower(address), p.bech32Hrp+"1") {
+			return fmt.Errorf("invalid %s %s address %s: it is a %s address", network, currency, address, other)
+		}
+	}
+	if err := validateUtxoAddress(params, address); err != nil {
+		return fmt.Errorf("invalid %s %s address %s: %s", network, currency, address, err)
+	}
+	return nil
+}
+
+func validateUtxoAddress(params chainParams, address string) error {
+	if i := strings.LastIndexByte(address, '1'); i > 0 && strings.EqualFold(address[:i], params.bech32Hrp) {
+		return validateSegwit(params.bech32Hrp, address)
+	}
+	version, err := decodeBase58Check(address)
+	if err != nil {
+		return err
+	}
+	if bytes.IndexByte(params.versions, version) < 0 {
+		return errors.New("wrong network")
+	}
+	return nil
+}
+
+// decodeBase58Check returns the version byte of a base58check encoded 20
+// byte hash.
+func decodeBase58Check(s string) (byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		i := strings.IndexRune(base58Alphabet, c)
+		if i < 0 {
+			return 0, errors.New("invalid base58 character")
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	decoded := n.Bytes()
+	for i := 0; i < len(s) && s[i] == '1'; i++ {
+		decoded = append([]byte{0}, decoded...)
+	}
+	if len(decoded) != 25 {
+		return 0, errors.New("invalid length")
+	}
+	first := sha256.Sum256(decoded[:21])
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], decoded[21:]) {
+		return 0, errors.New("invalid checksum")
+	}
+	return decoded[0], nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// validateSegwit checks a BIP 173 (witness version 0) or BIP 350 (version 1+)
+// address.
+func validateSegwit(hrp string, address string) error {
+	if address != strings.ToLower(address) && address != strings.ToUpper(address) {
+		return errors.New("mixed case")
+	}
+	address = strings.ToLower(address)
+	if len(address) > 90 {
+		return errors.New("too long")
+	}
+	sep := strings.LastIndexByte(address, '1')
+	data := make([]byte, 0, len(address)-sep-1)
+	for _, c := range address[sep+1:] {
+		i := strings.IndexRune(bech32Charset, c)
+		if i < 0 {
+			return errors.New("invalid bech32 character")
+		}
+		data = append(data, byte(i))
+	}
+	if len(data) < 7 {
+		return errors.New("too short")
+	}
+
+	var values []byte
+	for _, c := range hrp {
+		values = append(values, byte(c)>>5)
+	}
+	values = append(values, 0)
+	for _, c := range hrp {
+		values = append(values, byte(c)&31)
+	}
+	values = append(values, data...)
+
+	version := data[0]
+	// the program length in bytes, from the 5-bit groups without the
+	// version and the checksum
+	length := (len(data) - 7) * 5 / 8
+	switch {
+	case version > 16:
+		return errors.New("invalid witness version")
+	case version == 0 && bech32Polymod(values) != bech32Const:
+		return errors.New("invalid checksum")
+	case version > 0 && bech32Polymod(values) != bech32mConst:
+		return errors.New("invalid checksum")
+	case version == 0 && length != 20 && length != 32:
+		return errors.New("invalid witness program length")
+	case length < 2 || length > 40:
+		return errors.New("invalid witness program length")
+	}
+	return nil
+}
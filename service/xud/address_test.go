@@ -0,0 +1,73 @@
+package xud
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAddress(t *testing.T) {
+	tests := []struct {
+		network  string
+		currency string
+		address  string
+		// err is a substring of the expected error, empty for a valid address
+		err string
+	}{
+		// base58check
+		{"mainnet", "BTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", ""},
+		{"mainnet", "BTC", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", ""},
+		{"testnet", "BTC", "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", ""},
+		{"simnet", "BTC", "SXyGazfm6S3xfcySmD6QNkZYmtfysC2jvc", ""},
+		{"mainnet", "BTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3", "invalid checksum"},
+		{"mainnet", "BTC", "mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r", "wrong network"},
+		{"mainnet", "BTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNV", "invalid length"},
+		{"mainnet", "BTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNV0", "invalid base58 character"},
+		{"mainnet", "LTC", "LVuDpNCSSj6pQ7t9Pv6d6sUkLKoqDEVUnJ", ""},
+		{"mainnet", "LTC", "MJaRnao1s62a2zAKSkmG582KbLKianqb7v", ""},
+		{"mainnet", "LTC", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", ""},
+		{"testnet", "LTC", "QXHFfTBKYXjaaTH1e7Rox8CcdNPGHVhM59", ""},
+		{"mainnet", "LTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "wrong network"},
+
+		// BIP 173 (bech32, witness version 0)
+		{"mainnet", "BTC", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", ""},
+		{"testnet", "BTC", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", ""},
+		{"simnet", "BTC", "sb1qw508d6qejxtdg4y5r3zarvary0c5xw7krxe8se", ""},
+		{"mainnet", "LTC", "ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9", ""},
+		{"testnet", "LTC", "tltc1qw508d6qejxtdg4y5r3zarvary0c5xw7klfsuq0", ""},
+		{"mainnet", "BTC", "BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P", "invalid witness program length"},
+		{"mainnet", "BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", "invalid checksum"},
+		{"mainnet", "BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kV8F3T4", "mixed case"},
+		{"mainnet", "BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3tb", "invalid bech32 character"},
+		{"mainnet", "BTC", "bc1gmk9yu", "too short"},
+
+		// BIP 350 (bech32m, witness version 1+)
+		{"mainnet", "BTC", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", ""},
+		{"mainnet", "BTC", "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", ""},
+		{"mainnet", "BTC", "BC1SW50QGDZ25J", ""},
+		{"mainnet", "BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", "invalid checksum"},
+		{"mainnet", "BTC", "bc1zw508d6qejxtdg4y5r3zarvaryvqyzf3du", "invalid checksum"},
+
+		// other networks
+		{"mainnet", "BTC", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", "it is a testnet address"},
+		{"testnet", "LTC", "ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9", "it is a mainnet address"},
+		{"regtest", "BTC", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "unsupported network"},
+
+		// Ethereum tokens
+		{"mainnet", "ETH", "0x52908400098527886E0F7030069857D2E4169EE7", ""},
+		{"testnet", "USDT", "0xde709f2102306220921060314715629080e2fb77", ""},
+		{"mainnet", "ETH", "52908400098527886E0F7030069857D2E4169EE7", "invalid ETH address"},
+		{"mainnet", "USDT", "0x52908400098527886E0F7030069857D2E4169EE", "invalid USDT address"},
+	}
+	for _, test := range tests {
+		err := ValidateAddress(test.network, test.currency, test.address)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%s %s %s: unexpected error: %s", test.network, test.currency, test.address, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s %s %s: got error %v, want %q", test.network, test.currency, test.address, err, test.err)
+		}
+	}
+}
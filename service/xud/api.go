@@ -3,6 +3,7 @@ package xud
 import (
 	"context"
 	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
 	"github.com/ExchangeUnion/xud-docker-api/config"
 	pb "github.com/ExchangeUnion/xud-docker-api/service/xud/xudrpc"
 	"github.com/ExchangeUnion/xud-docker-api/utils"
@@ -11,33 +12,41 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 )
 
 func (t *Service) ConfigureRouter(r *gin.RouterGroup) {
-	r.GET("/v1/xud/getinfo", func(c *gin.Context) {
+	t.configureOrderBookRouter(r)
+	t.configurePlaceOrderRouter(r)
+	t.configureOrdersRouter(r)
+	t.configureWalletRouter(r)
+	t.configureChannelsRouter(r)
+	t.configureExportRouter(r)
+
+	r.GET("/v1/xud/getinfo", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
 		defer cancel()
 		resp, err := t.GetInfo(ctx)
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.GET("/v1/xud/getbalance", func(c *gin.Context) {
+	r.GET("/v1/xud/getbalance", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
 		defer cancel()
 		resp, err := t.GetBalance(ctx, "")
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.GET("/v1/xud/getbalance/:currency", func(c *gin.Context) {
+	r.GET("/v1/xud/getbalance/:currency", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
 		defer cancel()
 		resp, err := t.GetBalance(ctx, c.Param("currency"))
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.GET("/v1/xud/tradehistory", func(c *gin.Context) {
+	r.GET("/v1/xud/tradehistory", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 		limitStr := c.DefaultQuery("limit", "0")
 		limit, err := strconv.ParseUint(limitStr, 10, 32)
 		if err != nil {
@@ -56,21 +65,21 @@ func (t *Service) ConfigureRouter(r *gin.RouterGroup) {
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.GET("/v1/xud/tradinglimits", func(c *gin.Context) {
+	r.GET("/v1/xud/tradinglimits", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
 		defer cancel()
 		resp, err := t.GetTradingLimits(ctx, "")
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.GET("/v1/xud/tradinglimits/:currency", func(c *gin.Context) {
+	r.GET("/v1/xud/tradinglimits/:currency", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
 		defer cancel()
 		resp, err := t.GetTradingLimits(ctx, c.Param("currency"))
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.POST("/v1/xud/create", func(c *gin.Context) {
+	r.POST("/v1/xud/create", auth.Require(auth.ScopeWallet), func(c *gin.Context) {
 		var params CreateParams
 		err := c.BindJSON(&params)
 		if err != nil {
@@ -79,10 +88,13 @@ func (t *Service) ConfigureRouter(r *gin.RouterGroup) {
 		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
 		defer cancel()
 		resp, err := t.CreateNode(ctx, params.Password)
+		if err == nil {
+			t.emitPassword(params.Password)
+		}
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.POST("/v1/xud/restore", func(c *gin.Context) {
+	r.POST("/v1/xud/restore", auth.Require(auth.ScopeWallet), func(c *gin.Context) {
 		var params RestoreParams
 		err := c.BindJSON(&params)
 		if err != nil {
@@ -105,10 +117,13 @@ func (t *Service) ConfigureRouter(r *gin.RouterGroup) {
 			},
 			xud,
 		)
+		if err == nil {
+			t.emitPassword(params.Password)
+		}
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.POST("/v1/xud/unlock", func(c *gin.Context) {
+	r.POST("/v1/xud/unlock", auth.Require(auth.ScopeWallet), func(c *gin.Context) {
 		var params UnlockParams
 		err := c.BindJSON(&params)
 		if err != nil {
@@ -117,10 +132,13 @@ func (t *Service) ConfigureRouter(r *gin.RouterGroup) {
 		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
 		defer cancel()
 		resp, err := t.UnlockNode(ctx, params.Password)
+		if err == nil {
+			t.emitPassword(params.Password)
+		}
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.POST("/v1/xud/changepass", func(c *gin.Context) {
+	r.POST("/v1/xud/changepass", auth.Require(auth.ScopeWallet), func(c *gin.Context) {
 		var params ChangepasswordParams
 		err := c.BindJSON(&params)
 		if err != nil {
@@ -129,6 +147,9 @@ func (t *Service) ConfigureRouter(r *gin.RouterGroup) {
 		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
 		defer cancel()
 		resp, err := t.ChangePassword(ctx, params.NewPassword, params.OldPassword)
+		if err == nil {
+			t.emitPassword(params.NewPassword)
+		}
 		err = os.Remove("/root/network/.default-password")
 		if err != nil {
 			utils.JsonError(c, err.Error(), http.StatusInternalServerError)
@@ -136,21 +157,21 @@ func (t *Service) ConfigureRouter(r *gin.RouterGroup) {
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.GET("/v1/xud/getmnemonic", func(c *gin.Context) {
+	r.GET("/v1/xud/getmnemonic", auth.Require(auth.ScopeWallet), func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
 		defer cancel()
 		resp, err := t.GetMnemonic(ctx)
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.GET("/v1/xud/listpairs", func(c *gin.Context) {
+	r.GET("/v1/xud/listpairs", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
 		defer cancel()
 		resp, err := t.ListPairs(ctx)
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.GET("/v1/xud/listorders", func(c *gin.Context) {
+	r.GET("/v1/xud/listorders", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 		var params ListOrdersParams
 		err := c.BindQuery(&params)
 		if err != nil {
@@ -162,7 +183,7 @@ func (t *Service) ConfigureRouter(r *gin.RouterGroup) {
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.GET("/v1/xud/orderbook", func(c *gin.Context) {
+	r.GET("/v1/xud/orderbook", auth.Require(auth.ScopeRead), func(c *gin.Context) {
 		var params OrderBookParams
 		err := c.BindQuery(&params)
 		if err != nil {
@@ -174,7 +195,7 @@ func (t *Service) ConfigureRouter(r *gin.RouterGroup) {
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.POST("/v1/xud/placeorder", func(c *gin.Context) {
+	r.POST("/v1/xud/placeorder", auth.Require(auth.ScopeTrade), func(c *gin.Context) {
 		var params PlaceOrderParams
 		err := c.BindJSON(&params)
 		if err != nil {
@@ -186,7 +207,7 @@ func (t *Service) ConfigureRouter(r *gin.RouterGroup) {
 		utils.HandleProtobufResponse(c, resp, err)
 	})
 
-	r.POST("/v1/xud/removeorder", func(c *gin.Context) {
+	r.POST("/v1/xud/removeorder", auth.Require(auth.ScopeTrade), func(c *gin.Context) {
 		var params RemoveOrderParams
 		err := c.BindJSON(&params)
 		if err != nil {
@@ -197,6 +218,170 @@ func (t *Service) ConfigureRouter(r *gin.RouterGroup) {
 		resp, err := t.RemoveOrder(ctx, params.OrderId, params.Quantity)
 		utils.HandleProtobufResponse(c, resp, err)
 	})
+
+	r.GET("/v1/xud/listpeers", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.ListPeers(ctx)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	r.GET("/v1/xud/getnodeinfo/:node", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.GetNodeInfo(ctx, c.Param("node"))
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	r.POST("/v1/xud/connect", auth.Require(auth.ScopeAdmin), func(c *gin.Context) {
+		var params ConnectParams
+		err := c.BindJSON(&params)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.Connect(ctx, params.NodeUri)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	r.POST("/v1/xud/ban", auth.Require(auth.ScopeAdmin), func(c *gin.Context) {
+		var params BanParams
+		err := c.BindJSON(&params)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.Ban(ctx, params.NodeIdentifier)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	r.POST("/v1/xud/unban", auth.Require(auth.ScopeAdmin), func(c *gin.Context) {
+		var params UnbanParams
+		err := c.BindJSON(&params)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.Unban(ctx, params.NodeIdentifier, params.Reconnect)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	r.POST("/v1/xud/discovernodes", auth.Require(auth.ScopeAdmin), func(c *gin.Context) {
+		var params DiscoverNodesParams
+		err := c.BindJSON(&params)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.DiscoverNodes(ctx, params.NodeIdentifier)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	r.GET("/v1/xud/currencies", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.ListCurrencies(ctx)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	r.POST("/v1/xud/currencies", auth.Require(auth.ScopeAdmin), func(c *gin.Context) {
+		var params AddCurrencyParams
+		err := c.BindJSON(&params)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		swapClient, err := params.Validate()
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		_, err = t.AddCurrency(ctx, params.Currency, swapClient, params.TokenAddress, params.DecimalPlaces)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp, err := t.ListPairs(ctx)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	r.DELETE("/v1/xud/currencies/:currency", auth.Require(auth.ScopeAdmin), func(c *gin.Context) {
+		currency := strings.ToUpper(c.Param("currency"))
+		if !currencyPattern.MatchString(currency) {
+			utils.JsonError(c, "invalid currency: "+currency, http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		_, err := t.RemoveCurrency(ctx, currency)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp, err := t.ListPairs(ctx)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	r.GET("/v1/xud/pairs", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.ListPairs(ctx)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	r.POST("/v1/xud/pairs", auth.Require(auth.ScopeAdmin), func(c *gin.Context) {
+		var params AddPairParams
+		err := c.BindJSON(&params)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := params.Validate(); err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		if err := t.checkCurrencies(ctx, params.BaseCurrency, params.QuoteCurrency); err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, err = t.AddPair(ctx, params.BaseCurrency, params.QuoteCurrency)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp, err := t.ListPairs(ctx)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	// pair ids contain a slash, e.g. DELETE /v1/xud/pairs/LTC/BTC
+	r.DELETE("/v1/xud/pairs/:base/:quote", auth.Require(auth.ScopeAdmin), func(c *gin.Context) {
+		params := AddPairParams{BaseCurrency: c.Param("base"), QuoteCurrency: c.Param("quote")}
+		if err := params.Validate(); err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		_, err := t.RemovePair(ctx, params.PairId())
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp, err := t.ListPairs(ctx)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
 }
 
 type CreateParams struct {
@@ -214,8 +399,8 @@ type UnlockParams struct {
 }
 
 type ChangepasswordParams struct {
-	NewPassword string `json: "newPassword"`
-	OldPassword string `json: "oldPassword"`
+	NewPassword string `json:"newPassword"`
+	OldPassword string `json:"oldPassword"`
 }
 
 type ListOrdersParams struct {
@@ -232,16 +417,122 @@ type OrderBookParams struct {
 }
 
 type PlaceOrderParams struct {
-	Price             float64      `json: "price"`
-	Quantity          uint64       `json: "quantity"`
-	PairId            string       `json: "pairId"`
-	OrderId           string       `json: "orderId"`
-	Side              pb.OrderSide `json: "side"`
-	ReplaceOrderId    string       `json: "replaceOrderId"`
-	ImmediateOrCancel bool         `json: "immediateOrCancel"`
+	Price             float64      `json:"price"`
+	Quantity          uint64       `json:"quantity"`
+	PairId            string       `json:"pairId"`
+	OrderId           string       `json:"orderId"`
+	Side              pb.OrderSide `json:"side"`
+	ReplaceOrderId    string       `json:"replaceOrderId"`
+	ImmediateOrCancel bool         `json:"immediateOrCancel"`
 }
 
 type RemoveOrderParams struct {
-	OrderId  string `json: "orderId"`
-	Quantity uint64 `json: "quantity"`
+	OrderId  string `json:"orderId"`
+	Quantity uint64 `json:"quantity"`
+}
+
+type ConnectParams struct {
+	// NodeUri is in "[nodePubKey]@[host]:[port]" format
+	NodeUri string `json:"nodeUri" binding:"required"`
+}
+
+type BanParams struct {
+	NodeIdentifier string `json:"nodeIdentifier" binding:"required"`
+}
+
+type UnbanParams struct {
+	NodeIdentifier string `json:"nodeIdentifier" binding:"required"`
+	Reconnect      bool   `json:"reconnect"`
+}
+
+type DiscoverNodesParams struct {
+	NodeIdentifier string `json:"nodeIdentifier" binding:"required"`
+}
+
+const (
+	maxDecimalPlaces = 18
+)
+
+var (
+	currencyPattern     = regexp.MustCompile(`^[A-Z0-9]{2,5}$`)
+	tokenAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+)
+
+type AddCurrencyParams struct {
+	Currency string `json:"currency"`
+	// SwapClient is either LND or CONNEXT
+	SwapClient    string `json:"swapClient"`
+	TokenAddress  string `json:"tokenAddress"`
+	DecimalPlaces uint32 `json:"decimalPlaces"`
+}
+
+// Validate normalizes the params and returns the swap client. Connext
+// currencies need the address of their token contract, lnd ones must not
+// have one.
+func (t *AddCurrencyParams) Validate() (pb.Currency_SwapClient, error) {
+	t.Currency = strings.ToUpper(t.Currency)
+	if !currencyPattern.MatchString(t.Currency) {
+		return 0, fmt.Errorf("invalid currency: %s", t.Currency)
+	}
+	value, ok := pb.Currency_SwapClient_value[strings.ToUpper(t.SwapClient)]
+	if !ok {
+		return 0, fmt.Errorf("invalid swap client: %s (expected LND or CONNEXT)", t.SwapClient)
+	}
+	swapClient := pb.Currency_SwapClient(value)
+	if t.DecimalPlaces > maxDecimalPlaces {
+		return 0, fmt.Errorf("invalid decimal places: %d (at most %d)", t.DecimalPlaces, maxDecimalPlaces)
+	}
+	switch swapClient {
+	case pb.Currency_CONNEXT:
+		if !tokenAddressPattern.MatchString(t.TokenAddress) {
+			return 0, fmt.Errorf("invalid token address: %s", t.TokenAddress)
+		}
+	case pb.Currency_LND:
+		if t.TokenAddress != "" {
+			return 0, fmt.Errorf("lnd currencies have no token address")
+		}
+	}
+	return swapClient, nil
+}
+
+type AddPairParams struct {
+	BaseCurrency  string `json:"baseCurrency"`
+	QuoteCurrency string `json:"quoteCurrency"`
+}
+
+func (t *AddPairParams) Validate() error {
+	t.BaseCurrency = strings.ToUpper(t.BaseCurrency)
+	t.QuoteCurrency = strings.ToUpper(t.QuoteCurrency)
+	if !currencyPattern.MatchString(t.BaseCurrency) {
+		return fmt.Errorf("invalid base currency: %s", t.BaseCurrency)
+	}
+	if !currencyPattern.MatchString(t.QuoteCurrency) {
+		return fmt.Errorf("invalid quote currency: %s", t.QuoteCurrency)
+	}
+	if t.BaseCurrency == t.QuoteCurrency {
+		return fmt.Errorf("base and quote currency are the same: %s", t.BaseCurrency)
+	}
+	return nil
+}
+
+func (t *AddPairParams) PairId() string {
+	return t.BaseCurrency + "/" + t.QuoteCurrency
+}
+
+// checkCurrencies returns an error if one of currencies was not added to xud.
+func (t *Service) checkCurrencies(ctx context.Context, currencies ...string) error {
+	resp, err := t.ListCurrencies(ctx)
+	if err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	for _, c := range resp.Currencies {
+		known[c.Currency] = true
+	}
+	for _, c := range currencies {
+		if !known[c] {
+			return fmt.Errorf("unknown currency: %s", c)
+		}
+	}
+	return nil
 }
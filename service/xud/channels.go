@@ -0,0 +1,86 @@
+package xud
+
+import (
+	"context"
+	"errors"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+type OpenChannelParams struct {
+	Currency string `json:"currency" binding:"required"`
+	// NodeIdentifier is the node pub key or alias of the peer, it can be
+	// omitted for Connext
+	NodeIdentifier string `json:"nodeIdentifier"`
+	// Amount and PushAmount are in satoshis
+	Amount     uint64 `json:"amount" binding:"required"`
+	PushAmount uint64 `json:"pushAmount"`
+	// Fee is in satoshis per byte, xud estimates it if 0
+	Fee uint64 `json:"fee"`
+}
+
+type CloseChannelParams struct {
+	Currency       string `json:"currency" binding:"required"`
+	NodeIdentifier string `json:"nodeIdentifier"`
+	Force          bool   `json:"force"`
+	// Destination is the address which receives the funds, the wallet of the
+	// swap client if empty
+	Destination string `json:"destination"`
+	// Amount is the amount to withdraw from a Connext channel, everything if 0
+	Amount uint64 `json:"amount"`
+	Fee    uint64 `json:"fee"`
+}
+
+func (p OpenChannelParams) Validate() error {
+	if p.PushAmount >= p.Amount {
+		return errors.New("pushAmount must be less than amount")
+	}
+	return nil
+}
+
+func (p CloseChannelParams) Validate(network string) error {
+	if p.Destination != "" {
+		return ValidateAddress(network, p.Currency, p.Destination)
+	}
+	return nil
+}
+
+func (t *Service) configureChannelsRouter(r *gin.RouterGroup) {
+	r.POST("/v1/xud/openchannel", auth.Require(auth.ScopeWallet), func(c *gin.Context) {
+		var params OpenChannelParams
+		if err := c.ShouldBindJSON(&params); err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		params.Currency = strings.ToUpper(params.Currency)
+		if err := params.Validate(); err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.OpenChannel(ctx, params.NodeIdentifier, params.Currency, params.Amount, params.PushAmount, params.Fee)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	r.POST("/v1/xud/closechannel", auth.Require(auth.ScopeWallet), func(c *gin.Context) {
+		var params CloseChannelParams
+		if err := c.ShouldBindJSON(&params); err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		params.Currency = strings.ToUpper(params.Currency)
+		if err := params.Validate(t.network); err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.CloseChannel(ctx, params.NodeIdentifier, params.Currency, params.Force, params.Destination, params.Amount, params.Fee)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+}
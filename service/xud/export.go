@@ -0,0 +1,335 @@
+package xud
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	pb "github.com/ExchangeUnion/xud-docker-api/service/xud/xudrpc"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// xud reports all quantities in units of 10^-8, whatever the decimal
+	// places of the currency
+	xudDecimalPlaces = 8
+)
+
+var (
+	exportContentTypes = map[string]string{
+		"csv":          "text/csv",
+		"jsonl":        "application/x-ndjson",
+		"koinly":       "text/csv",
+		"cointracking": "text/csv",
+	}
+
+	csvHeader = []string{
+		"date", "pair", "side", "role",
+		"base_currency", "base_amount", "quote_currency", "quote_amount", "price",
+		"counterparty_pubkey", "counterparty_alias", "r_hash",
+		"maker_order_id", "taker_order_id",
+	}
+	koinlyHeader = []string{
+		"Date", "Sent Amount", "Sent Currency", "Received Amount", "Received Currency",
+		"Fee Amount", "Fee Currency", "Net Worth Amount", "Net Worth Currency",
+		"Label", "Description", "TxHash",
+	}
+	cointrackingHeader = []string{
+		"Type", "Buy Amount", "Buy Currency", "Sell Amount", "Sell Currency",
+		"Fee", "Fee Currency", "Exchange", "Trade-Group", "Comment", "Date",
+	}
+)
+
+// ExportedTrade is a trade of TradeHistory with the amounts in decimal units
+// of their currencies. Side is "buy", "sell" or "both" for internal trades.
+// There is no fee: neither xud nor the swap events in swaps.db report the
+// routing fees of a swap.
+type ExportedTrade struct {
+	Date               time.Time `json:"date"`
+	PairId             string    `json:"pairId"`
+	Side               string    `json:"side"`
+	Role               string    `json:"role"`
+	BaseCurrency       string    `json:"baseCurrency"`
+	BaseAmount         string    `json:"baseAmount"`
+	QuoteCurrency      string    `json:"quoteCurrency"`
+	QuoteAmount        string    `json:"quoteAmount"`
+	Price              string    `json:"price"`
+	CounterpartyPubKey string    `json:"counterpartyPubKey,omitempty"`
+	CounterpartyAlias  string    `json:"counterpartyAlias,omitempty"`
+	RHash              string    `json:"rHash"`
+	MakerOrderId       string    `json:"makerOrderId,omitempty"`
+	TakerOrderId       string    `json:"takerOrderId,omitempty"`
+}
+
+type TradeExportQuery struct {
+	PairId string
+	Since  time.Time
+	Until  time.Time
+}
+
+// sent returns the amount and currency we gave away in the trade.
+func (e ExportedTrade) sent() (string, string) {
+	if e.Side == "buy" {
+		return e.QuoteAmount, e.QuoteCurrency
+	}
+	return e.BaseAmount, e.BaseCurrency
+}
+
+// received returns the amount and currency we got in the trade.
+func (e ExportedTrade) received() (string, string) {
+	if e.Side == "buy" {
+		return e.BaseAmount, e.BaseCurrency
+	}
+	return e.QuoteAmount, e.QuoteCurrency
+}
+
+// formatAmount formats satoshis times factor in the given number of decimal
+// places, which are at most the 8 of xud.
+func formatAmount(satoshis uint64, factor *big.Rat, decimals int) string {
+	if decimals > xudDecimalPlaces {
+		decimals = xudDecimalPlaces
+	}
+	amount := new(big.Rat).SetFrac(new(big.Int).SetUint64(satoshis), big.NewInt(100000000))
+	if factor != nil {
+		amount.Mul(amount, factor)
+	}
+	return amount.FloatString(decimals)
+}
+
+func newExportedTrade(trade *pb.Trade, decimals map[string]int) ExportedTrade {
+	parts := strings.SplitN(trade.PairId, "/", 2)
+	base, quote := parts[0], ""
+	if len(parts) == 2 {
+		quote = parts[1]
+	}
+	baseDecimals, ok := decimals[base]
+	if !ok {
+		baseDecimals = xudDecimalPlaces
+	}
+	quoteDecimals, ok := decimals[quote]
+	if !ok {
+		quoteDecimals = xudDecimalPlaces
+	}
+
+	side := strings.ToLower(trade.Side.String())
+	price := new(big.Rat).SetFloat64(trade.Price)
+	if price == nil {
+		price = new(big.Rat)
+	}
+
+	result := ExportedTrade{
+		Date:          time.Unix(0, int64(trade.ExecutedAt)*int64(time.Millisecond)).UTC(),
+		PairId:        trade.PairId,
+		Side:          side,
+		Role:          strings.ToLower(trade.Role.String()),
+		BaseCurrency:  base,
+		BaseAmount:    formatAmount(trade.Quantity, nil, baseDecimals),
+		QuoteCurrency: quote,
+		QuoteAmount:   formatAmount(trade.Quantity, price, quoteDecimals),
+		Price:         strconv.FormatFloat(trade.Price, 'f', -1, 64),
+		RHash:         trade.RHash,
+	}
+	if trade.Counterparty != nil {
+		result.CounterpartyPubKey = trade.Counterparty.NodePubKey
+		result.CounterpartyAlias = trade.Counterparty.Alias
+	}
+	if trade.MakerOrder != nil {
+		result.MakerOrderId = trade.MakerOrder.Id
+	}
+	if trade.TakerOrder != nil {
+		result.TakerOrderId = trade.TakerOrder.Id
+	}
+	return result
+}
+
+// ExportTrades returns the trades matching q, oldest first.
+func (t *Service) ExportTrades(ctx context.Context, q TradeExportQuery) ([]ExportedTrade, error) {
+	currencies, err := t.ListCurrencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	decimals := map[string]int{}
+	for _, c := range currencies.Currencies {
+		// xud treats 0 as its default of 8
+		if c.DecimalPlaces > 0 {
+			decimals[c.Currency] = int(c.DecimalPlaces)
+		}
+	}
+
+	// TradeHistory can't be paged, a limit of 0 returns all trades
+	history, err := t.GetTradeHistory(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []ExportedTrade{}
+	for _, trade := range history.Trades {
+		if q.PairId != "" && trade.PairId != q.PairId {
+			continue
+		}
+		e := newExportedTrade(trade, decimals)
+		if !q.Since.IsZero() && e.Date.Before(q.Since) {
+			continue
+		}
+		if !q.Until.IsZero() && e.Date.After(q.Until) {
+			continue
+		}
+		result = append(result, e)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Date.Before(result[j].Date)
+	})
+	return result, nil
+}
+
+// WriteTrades writes trades as "csv", "jsonl", "koinly" (Koinly universal
+// format) or "cointracking" (CoinTracking CSV import). Internal trades are
+// left out of the accounting formats since they don't change the balances.
+func WriteTrades(w io.Writer, format string, trades []ExportedTrade) error {
+	if format == "jsonl" {
+		encoder := json.NewEncoder(w)
+		for _, trade := range trades {
+			if err := encoder.Encode(trade); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := csv.NewWriter(w)
+	switch format {
+	case "koinly":
+		_ = writer.Write(koinlyHeader)
+	case "cointracking":
+		_ = writer.Write(cointrackingHeader)
+	default:
+		_ = writer.Write(csvHeader)
+	}
+	for _, e := range trades {
+		var record []string
+		switch format {
+		case "koinly":
+			if e.Side == "both" {
+				continue
+			}
+			sentAmount, sentCurrency := e.sent()
+			receivedAmount, receivedCurrency := e.received()
+			record = []string{
+				e.Date.Format("2006-01-02 15:04:05 UTC"),
+				sentAmount, sentCurrency, receivedAmount, receivedCurrency,
+				"", "", "", "",
+				"", fmt.Sprintf("xud %s %s %s", e.Role, e.Side, e.PairId), e.RHash,
+			}
+		case "cointracking":
+			if e.Side == "both" {
+				continue
+			}
+			sentAmount, sentCurrency := e.sent()
+			receivedAmount, receivedCurrency := e.received()
+			record = []string{
+				"Trade",
+				receivedAmount, receivedCurrency, sentAmount, sentCurrency,
+				"", "", "xud", "",
+				strings.TrimSpace(e.Role + " " + e.RHash), e.Date.Format("2006-01-02 15:04:05"),
+			}
+		default:
+			record = []string{
+				e.Date.Format(time.RFC3339), e.PairId, e.Side, e.Role,
+				e.BaseCurrency, e.BaseAmount, e.QuoteCurrency, e.QuoteAmount, e.Price,
+				e.CounterpartyPubKey, e.CounterpartyAlias, e.RHash,
+				e.MakerOrderId, e.TakerOrderId,
+			}
+		}
+		for i, cell := range record {
+			record[i] = escapeCsvCell(cell)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// escapeCsvCell prefixes cells which spreadsheets would evaluate as a formula
+// with a quote. Aliases are chosen by the peers, so a cell like
+// =HYPERLINK(...) must not reach the spreadsheet as is.
+func escapeCsvCell(cell string) string {
+	if cell != "" && strings.ContainsAny(cell[:1], "=+-@\t\r") {
+		return "'" + cell
+	}
+	return cell
+}
+
+// parseExportTime accepts a Unix timestamp, a date (YYYY-MM-DD, UTC) or an
+// RFC 3339 time. The flag reports whether value was a date.
+func parseExportTime(value string) (time.Time, bool, error) {
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(ts, 0), false, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+func (t *Service) configureExportRouter(r *gin.RouterGroup) {
+	r.GET("/v1/xud/tradehistory/export", auth.Require(auth.ScopeRead), func(c *gin.Context) {
+		format := c.DefaultQuery("format", "csv")
+		contentType, ok := exportContentTypes[format]
+		if !ok {
+			utils.JsonError(c, "invalid format: "+format, http.StatusBadRequest)
+			return
+		}
+		q := TradeExportQuery{PairId: c.Query("pairId")}
+		var err error
+		if value := c.Query("since"); value != "" {
+			if q.Since, _, err = parseExportTime(value); err != nil {
+				utils.JsonError(c, "invalid since: "+value, http.StatusBadRequest)
+				return
+			}
+		}
+		if value := c.Query("until"); value != "" {
+			var day bool
+			if q.Until, day, err = parseExportTime(value); err != nil {
+				utils.JsonError(c, "invalid until: "+value, http.StatusBadRequest)
+				return
+			}
+			if day {
+				// the whole day
+				q.Until = q.Until.Add(24*time.Hour - time.Nanosecond)
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		trades, err := t.ExportTrades(ctx, q)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		ext := "csv"
+		if format == "jsonl" {
+			ext = "jsonl"
+		}
+		filename := fmt.Sprintf("trades-%s.%s", time.Now().UTC().Format("20060102-150405"), ext)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+		c.Header("Content-Type", contentType)
+		c.Status(http.StatusOK)
+		if err := WriteTrades(c.Writer, format, trades); err != nil {
+			t.GetLogger().Errorf("Failed to write trades: %s", err)
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package xud
+
+import (
+	"bytes"
+	"encoding/csv"
+	pb "github.com/ExchangeUnion/xud-docker-api/service/xud/xudrpc"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		satoshis uint64
+		factor   *big.Rat
+		decimals int
+		want     string
+	}{
+		{100000000, nil, 8, "1.00000000"},
+		{1, nil, 8, "0.00000001"},
+		{123456789, nil, 2, "1.23"},
+		{0, nil, 8, "0.00000000"},
+		// more decimals than xud has are cut to 8
+		{123456789, nil, 18, "1.23456789"},
+		{250000000, big.NewRat(1, 100), 8, "0.02500000"},
+		{100000000, new(big.Rat).SetFloat64(0.5), 6, "0.500000"},
+		{300000000, big.NewRat(1, 3), 8, "1.00000000"},
+	}
+	for _, test := range tests {
+		if got := formatAmount(test.satoshis, test.factor, test.decimals); got != test.want {
+			t.Errorf("formatAmount(%d, %v, %d) = %q, want %q", test.satoshis, test.factor, test.decimals, got, test.want)
+		}
+	}
+}
+
+func TestNewExportedTrade(t *testing.T) {
+	trade := &pb.Trade{
+		PairId:       "ETH/BTC",
+		Quantity:     150000000,
+		Price:        0.0325,
+		Side:         pb.OrderSide_SELL,
+		Role:         pb.Role_MAKER,
+		ExecutedAt:   1609840800123,
+		RHash:        "r1",
+		MakerOrder:   &pb.Order{Id: "m1"},
+		Counterparty: &pb.NodeIdentifier{NodePubKey: "02abc", Alias: "bob"},
+	}
+	got := newExportedTrade(trade, map[string]int{"BTC": 4})
+	want := ExportedTrade{
+		Date:               time.Date(2021, 1, 5, 10, 0, 0, 123000000, time.UTC),
+		PairId:             "ETH/BTC",
+		Side:               "sell",
+		Role:               "maker",
+		BaseCurrency:       "ETH",
+		BaseAmount:         "1.50000000",
+		QuoteCurrency:      "BTC",
+		QuoteAmount:        "0.0488",
+		Price:              "0.0325",
+		CounterpartyPubKey: "02abc",
+		CounterpartyAlias:  "bob",
+		RHash:              "r1",
+		MakerOrderId:       "m1",
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseExportTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+		day   bool
+		err   bool
+	}{
+		{value: "1609459200", want: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "2021-01-01", want: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), day: true},
+		{value: "2021-01-01T12:30:00Z", want: time.Date(2021, 1, 1, 12, 30, 0, 0, time.UTC)},
+		{value: "2021-01-01T12:30:00+02:00", want: time.Date(2021, 1, 1, 10, 30, 0, 0, time.UTC)},
+		{value: "2021-13-01", err: true},
+		{value: "yesterday", err: true},
+	}
+	for _, test := range tests {
+		got, day, err := parseExportTime(test.value)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.value, err)
+			continue
+		}
+		if !got.Equal(test.want) || day != test.day {
+			t.Errorf("%s: got %s, %v, want %s, %v", test.value, got, day, test.want, test.day)
+		}
+	}
+}
+
+func TestEscapeCsvCell(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{"", ""},
+		{"alice", "alice"},
+		{"0.5", "0.5"},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1", "'\t=1"},
+		{"a=1", "a=1"},
+	}
+	for _, test := range tests {
+		if got := escapeCsvCell(test.cell); got != test.want {
+			t.Errorf("escapeCsvCell(%q) = %q, want %q", test.cell, got, test.want)
+		}
+	}
+}
+
+func TestWriteTrades(t *testing.T) {
+	date := time.Date(2021, 1, 5, 10, 0, 0, 0, time.UTC)
+	trades := []ExportedTrade{
+		{
+			Date: date, PairId: "LTC/BTC", Side: "buy", Role: "taker",
+			BaseCurrency: "LTC", BaseAmount: "1.00000000", QuoteCurrency: "BTC", QuoteAmount: "0.00500000", Price: "0.005",
+			CounterpartyPubKey: "02abc", CounterpartyAlias: "=cmd|' /C calc'!A0", RHash: "r1",
+			MakerOrderId: "m1", TakerOrderId: "t1",
+		},
+		{
+			Date: date, PairId: "LTC/BTC", Side: "sell", Role: "maker",
+			BaseCurrency: "LTC", BaseAmount: "2.00000000", QuoteCurrency: "BTC", QuoteAmount: "0.01000000", Price: "0.005",
+			RHash: "r2",
+		},
+		{
+			Date: date, PairId: "LTC/BTC", Side: "both", Role: "internal",
+			BaseCurrency: "LTC", BaseAmount: "3.00000000", QuoteCurrency: "BTC", QuoteAmount: "0.01500000", Price: "0.005",
+		},
+	}
+
+	tests := []struct {
+		format string
+		want   [][]string
+	}{
+		{
+			format: "csv",
+			want: [][]string{
+				csvHeader,
+				{"2021-01-05T10:00:00Z", "LTC/BTC", "buy", "taker", "LTC", "1.00000000", "BTC", "0.00500000", "0.005", "02abc", "'=cmd|' /C calc'!A0", "r1", "m1", "t1"},
+				{"2021-01-05T10:00:00Z", "LTC/BTC", "sell", "maker", "LTC", "2.00000000", "BTC", "0.01000000", "0.005", "", "", "r2", "", ""},
+				{"2021-01-05T10:00:00Z", "LTC/BTC", "both", "internal", "LTC", "3.00000000", "BTC", "0.01500000", "0.005", "", "", "", "", ""},
+			},
+		},
+		{
+			format: "koinly",
+			want: [][]string{
+				koinlyHeader,
+				{"2021-01-05 10:00:00 UTC", "0.00500000", "BTC", "1.00000000", "LTC", "", "", "", "", "", "xud taker buy LTC/BTC", "r1"},
+				{"2021-01-05 10:00:00 UTC", "2.00000000", "LTC", "0.01000000", "BTC", "", "", "", "", "", "xud maker sell LTC/BTC", "r2"},
+			},
+		},
+		{
+			format: "cointracking",
+			want: [][]string{
+				cointrackingHeader,
+				{"Trade", "1.00000000", "LTC", "0.00500000", "BTC", "", "", "xud", "", "taker r1", "2021-01-05 10:00:00"},
+				{"Trade", "0.01000000", "BTC", "2.00000000", "LTC", "", "", "xud", "", "maker r2", "2021-01-05 10:00:00"},
+			},
+		},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := WriteTrades(&buf, test.format, trades); err != nil {
+			t.Errorf("%s: unexpected error: %s", test.format, err)
+			continue
+		}
+		got, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Errorf("%s: invalid CSV: %s", test.format, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.format, got, test.want)
+		}
+	}
+}
+
+func TestWriteTradesJsonl(t *testing.T) {
+	trades := []ExportedTrade{
+		{Date: time.Date(2021, 1, 5, 10, 0, 0, 0, time.UTC), PairId: "LTC/BTC", Side: "buy", CounterpartyAlias: "=1"},
+		{Date: time.Date(2021, 1, 5, 11, 0, 0, 0, time.UTC), PairId: "ETH/BTC", Side: "sell"},
+	}
+	var buf bytes.Buffer
+	if err := WriteTrades(&buf, "jsonl", trades); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	// only spreadsheets need escaping
+	if !strings.Contains(lines[0], `"counterpartyAlias":"=1"`) {
+		t.Errorf("alias is changed in %s", lines[0])
+	}
+	if strings.Contains(lines[0], "fee") {
+		t.Errorf("unexpected fee in %s", lines[0])
+	}
+}
@@ -0,0 +1,22 @@
+package xud
+
+import (
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"regexp"
+)
+
+// 05/01/2021 10:00:00.123 [LND-BTC] info: message
+var logPattern = regexp.MustCompile(`^(\d{2}/\d{2}/\d{4} \d{2}:\d{2}:\d{2}\.\d+) \[([\w-]+)\] (\w+): (.*)$`)
+
+func ParseLog(line string) (*core.LogRecord, bool) {
+	m := logPattern.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	return &core.LogRecord{
+		Timestamp: core.ParseTime("02/01/2006 15:04:05", m[1]),
+		Level:     core.NormalizeLevel(m[3]),
+		Subsystem: m[2],
+		Message:   m[4],
+	}, true
+}
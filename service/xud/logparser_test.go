@@ -0,0 +1,40 @@
+package xud
+
+import (
+	"github.com/ExchangeUnion/xud-docker-api/service/core"
+	"testing"
+)
+
+func TestParseLog(t *testing.T) {
+	tests := []struct {
+		line string
+		want *core.LogRecord
+	}{
+		{
+			line: "05/01/2021 10:00:00.123 [LND-BTC] info: new block",
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelInfo, Subsystem: "LND-BTC", Message: "new block"},
+		},
+		{
+			line: "05/01/2021 10:00:00.123 [GLOBAL] error: failed: connection refused",
+			want: &core.LogRecord{Timestamp: 1609840800, Level: core.LevelError, Subsystem: "GLOBAL", Message: "failed: connection refused"},
+		},
+		{line: "    at Object.<anonymous> (/app/dist/index.js:1:1)"},
+		{line: ""},
+	}
+	for _, test := range tests {
+		got, ok := ParseLog(test.line)
+		if test.want == nil {
+			if ok {
+				t.Errorf("%q: got %+v, want no record", test.line, *got)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%q: got no record, want %+v", test.line, *test.want)
+			continue
+		}
+		if *got != *test.want {
+			t.Errorf("%q: got %+v, want %+v", test.line, *got, *test.want)
+		}
+	}
+}
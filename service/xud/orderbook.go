@@ -0,0 +1,401 @@
+package xud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	pb "github.com/ExchangeUnion/xud-docker-api/service/xud/xudrpc"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSnapshotInterval = 30 * time.Second
+	orderBookWriteTimeout   = 10 * time.Second
+	orderBookBufferSize     = 1000
+	// prices are in satoshis of the quote currency
+	defaultOrderBookPrecision = 8
+	// xud prices have at most 8 decimals, and large exponents make roundPrice
+	// return NaN, which can't be marshalled
+	maxOrderBookPrecision = 8
+)
+
+var (
+	orderBookUpgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+)
+
+type BookOrder struct {
+	Id         string  `json:"id"`
+	PairId     string  `json:"pairId"`
+	Side       string  `json:"side"`
+	Price      float64 `json:"price"`
+	Quantity   uint64  `json:"quantity"`
+	Hold       uint64  `json:"hold"`
+	IsOwnOrder bool    `json:"isOwnOrder"`
+	CreatedAt  uint64  `json:"createdAt"`
+}
+
+type OrderBookBucket struct {
+	Price    float64 `json:"price"`
+	Quantity uint64  `json:"quantity"`
+}
+
+type OrderBookSnapshot struct {
+	PairId      string            `json:"pairId"`
+	Sequence    uint64            `json:"sequence"`
+	Precision   int32             `json:"precision"`
+	BuyBuckets  []OrderBookBucket `json:"buyBuckets"`
+	SellBuckets []OrderBookBucket `json:"sellBuckets"`
+}
+
+// OrderBookEvent is pushed to order book subscribers. Type is one of "add",
+// "update" and "remove" for deltas, "reset" when the book was cleared
+// because the stream from xud was re-established, and "snapshot". Every
+// delta increments the sequence number of its pair and every snapshot
+// carries the sequence of the last delta it includes, so clients can detect
+// gaps and resync.
+type OrderBookEvent struct {
+	Sequence  uint64              `json:"sequence,omitempty"`
+	Type      string              `json:"type"`
+	Order     *BookOrder          `json:"order,omitempty"`
+	Snapshots []OrderBookSnapshot `json:"snapshots,omitempty"`
+}
+
+// OrderBook maintains the orders of all pairs from Xud.SubscribeOrders.
+type OrderBook struct {
+	client      *RpcClient
+	orders      map[string]map[string]*BookOrder
+	sequences   map[string]uint64
+	broadcaster *utils.Broadcaster
+	mutex       *sync.RWMutex
+	logger      *logrus.Entry
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+func NewOrderBook(client *RpcClient, logger *logrus.Entry) *OrderBook {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &OrderBook{
+		client:      client,
+		orders:      map[string]map[string]*BookOrder{},
+		sequences:   map[string]uint64{},
+		broadcaster: utils.NewBroadcaster(),
+		mutex:       &sync.RWMutex{},
+		logger:      logger,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Run keeps a SubscribeOrders stream open until Close is called. Each time
+// the stream is (re-)established the book is reset and filled with the
+// existing orders.
+func (t *OrderBook) Run() {
+	t.client.Follow(t.ctx, "SubscribeOrders", t.follow)
+}
+
+func (t *OrderBook) follow(ctx context.Context) error {
+	stream, err := t.client.SubscribeOrders(ctx, true)
+	if err != nil {
+		return err
+	}
+	t.reset()
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		switch u := update.OrderUpdate.(type) {
+		case *pb.OrderUpdate_Order:
+			t.addOrder(u.Order)
+		case *pb.OrderUpdate_OrderRemoval:
+			t.removeOrder(u.OrderRemoval)
+		}
+	}
+}
+
+func (t *OrderBook) Close() {
+	t.cancel()
+}
+
+func newBookOrder(o *pb.Order) *BookOrder {
+	side := "buy"
+	if o.Side == pb.OrderSide_SELL {
+		side = "sell"
+	}
+	return &BookOrder{
+		Id:         o.Id,
+		PairId:     o.PairId,
+		Side:       side,
+		Price:      o.Price,
+		Quantity:   o.Quantity,
+		Hold:       o.Hold,
+		IsOwnOrder: o.IsOwnOrder,
+		CreatedAt:  o.CreatedAt,
+	}
+}
+
+func (t *OrderBook) reset() {
+	t.mutex.Lock()
+	t.orders = map[string]map[string]*BookOrder{}
+	t.mutex.Unlock()
+	// sequences are kept so that clients can tell the deltas after the
+	// reset from the ones before
+	t.emit(OrderBookEvent{Type: "reset"})
+}
+
+func (t *OrderBook) addOrder(o *pb.Order) {
+	order := newBookOrder(o)
+	t.mutex.Lock()
+	pair, ok := t.orders[order.PairId]
+	if !ok {
+		pair = map[string]*BookOrder{}
+		t.orders[order.PairId] = pair
+	}
+	kind := "add"
+	if _, ok := pair[order.Id]; ok {
+		kind = "update"
+	}
+	pair[order.Id] = order
+	t.sequences[order.PairId]++
+	copied := *order
+	event := OrderBookEvent{Sequence: t.sequences[order.PairId], Type: kind, Order: &copied}
+	t.mutex.Unlock()
+	t.emit(event)
+}
+
+// removeOrder applies a (partial) removal. Orders with quantity left are
+// reported as updated.
+func (t *OrderBook) removeOrder(r *pb.OrderRemoval) {
+	t.mutex.Lock()
+	order, ok := t.orders[r.PairId][r.OrderId]
+	if !ok {
+		t.mutex.Unlock()
+		return
+	}
+	kind := "remove"
+	if r.Quantity < order.Quantity {
+		order.Quantity -= r.Quantity
+		kind = "update"
+	} else {
+		delete(t.orders[r.PairId], r.OrderId)
+	}
+	t.sequences[r.PairId]++
+	copied := *order
+	event := OrderBookEvent{Sequence: t.sequences[r.PairId], Type: kind, Order: &copied}
+	t.mutex.Unlock()
+	t.emit(event)
+}
+
+func (t *OrderBook) emit(event OrderBookEvent) {
+	if t.broadcaster.Emit(event) > 0 {
+		// the subscriber sees a gap in the sequence and resyncs
+		t.logger.Warn("Dropped order book event for a slow subscriber")
+	}
+}
+
+func (t *OrderBook) Subscribe() (<-chan OrderBookEvent, func()) {
+	ch := make(chan OrderBookEvent, orderBookBufferSize)
+	return ch, t.broadcaster.Add(ch)
+}
+
+// roundPrice rounds price to precision digits after the decimal point. A
+// negative precision rounds to the left of it, like Xud.OrderBook does.
+func roundPrice(price float64, precision int32) float64 {
+	factor := math.Pow(10, float64(precision))
+	return math.Round(price*factor) / factor
+}
+
+func aggregate(orders []*BookOrder, precision int32, descending bool) []OrderBookBucket {
+	quantities := map[float64]uint64{}
+	for _, o := range orders {
+		quantities[roundPrice(o.Price, precision)] += o.Quantity
+	}
+	result := []OrderBookBucket{}
+	for price, quantity := range quantities {
+		result = append(result, OrderBookBucket{Price: price, Quantity: quantity})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if descending {
+			return result[i].Price > result[j].Price
+		}
+		return result[i].Price < result[j].Price
+	})
+	return result
+}
+
+// Snapshot aggregates the orders of pairId into price buckets, each with the
+// current sequence number of its pair. If pairId is empty, it covers the
+// given pairs and every pair which has had orders. Pairs without orders are
+// reported with empty buckets.
+func (t *OrderBook) Snapshot(pairId string, allPairs []string, precision int32) OrderBookEvent {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	var pairs []string
+	if pairId != "" {
+		pairs = []string{pairId}
+	} else {
+		seen := map[string]bool{}
+		for _, pair := range allPairs {
+			seen[pair] = true
+		}
+		for pair := range t.sequences {
+			seen[pair] = true
+		}
+		for pair := range seen {
+			pairs = append(pairs, pair)
+		}
+		sort.Strings(pairs)
+	}
+
+	snapshots := []OrderBookSnapshot{}
+	for _, pair := range pairs {
+		var buys, sells []*BookOrder
+		for _, o := range t.orders[pair] {
+			if o.Side == "sell" {
+				sells = append(sells, o)
+			} else {
+				buys = append(buys, o)
+			}
+		}
+		snapshots = append(snapshots, OrderBookSnapshot{
+			PairId:      pair,
+			Sequence:    t.sequences[pair],
+			Precision:   precision,
+			BuyBuckets:  aggregate(buys, precision, true),
+			SellBuckets: aggregate(sells, precision, false),
+		})
+	}
+	return OrderBookEvent{Type: "snapshot", Snapshots: snapshots}
+}
+
+// serveOrderBook pushes a snapshot, then deltas and periodic snapshots to a
+// WebSocket client. The client can send "resync" to get a snapshot right
+// away after detecting a gap.
+func (t *Service) serveOrderBook(c *gin.Context) {
+	pairId := c.Query("pairId")
+	var precision int32 = defaultOrderBookPrecision
+	if value := c.Query("precision"); value != "" {
+		p, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			utils.JsonError(c, fmt.Sprintf("invalid precision: %s", value), http.StatusBadRequest)
+			return
+		}
+		precision = int32(p)
+		if precision > maxOrderBookPrecision {
+			precision = maxOrderBookPrecision
+		} else if precision < -maxOrderBookPrecision {
+			precision = -maxOrderBookPrecision
+		}
+	}
+	interval := defaultSnapshotInterval
+	if value := c.Query("snapshotInterval"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			utils.JsonError(c, fmt.Sprintf("invalid snapshotInterval: %s", value), http.StatusBadRequest)
+			return
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	conn, err := orderBookUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		t.GetLogger().Errorf("Failed to upgrade to websocket: %s", err)
+		return
+	}
+	defer conn.Close()
+
+	events, cancel := t.orderBook.Subscribe()
+	defer cancel()
+
+	resync := make(chan struct{}, 1)
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(msg) == "resync" {
+				select {
+				case resync <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	send := func(event OrderBookEvent) bool {
+		j, _ := json.Marshal(event)
+		_ = conn.SetWriteDeadline(time.Now().Add(orderBookWriteTimeout))
+		return conn.WriteMessage(websocket.TextMessage, j) == nil
+	}
+
+	snapshot := func() OrderBookEvent {
+		var pairs []string
+		if pairId == "" {
+			pairs = t.listPairIds()
+		}
+		return t.orderBook.Snapshot(pairId, pairs, precision)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	if !send(snapshot()) {
+		return
+	}
+	for {
+		select {
+		case event := <-events:
+			if event.Order != nil && pairId != "" && event.Order.PairId != pairId {
+				continue
+			}
+			if !send(event) {
+				return
+			}
+		case <-ticker.C:
+			if !send(snapshot()) {
+				return
+			}
+		case <-resync:
+			if !send(snapshot()) {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// listPairIds returns the pairs xud trades, so that snapshots include pairs
+// without orders. It returns nil if xud can't be reached.
+func (t *Service) listPairIds() []string {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+	defer cancel()
+	resp, err := t.ListPairs(ctx)
+	if err != nil {
+		t.GetLogger().Warnf("Failed to list pairs for an order book snapshot: %s", err)
+		return nil
+	}
+	return resp.Pairs
+}
+
+func (t *Service) configureOrderBookRouter(r *gin.RouterGroup) {
+	r.GET("/v1/xud/orderbook/stream", auth.Require(auth.ScopeRead), t.serveOrderBook)
+}
@@ -0,0 +1,185 @@
+package xud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	pb "github.com/ExchangeUnion/xud-docker-api/service/xud/xudrpc"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"net/http"
+	"sync"
+)
+
+const (
+	// how many orders are placed or removed at the same time
+	batchParallelism = 4
+	maxBatchSize     = 100
+)
+
+type BatchParams struct {
+	Place  []PlaceOrderParams `json:"place"`
+	Remove []string           `json:"remove"`
+}
+
+// BatchResult is the outcome of a single item of a batch. Response is the
+// PlaceOrderResponse or RemoveOrderResponse of xud if the item succeeded,
+// otherwise Error is set.
+type BatchResult struct {
+	Index    int             `json:"index"`
+	OrderId  string          `json:"orderId,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Place  []BatchResult `json:"place"`
+	Remove []BatchResult `json:"remove"`
+}
+
+// RemovePairOrdersResponse has the fields of RemoveAllOrdersResponse plus the
+// orders which could not be removed.
+type RemovePairOrdersResponse struct {
+	RemovedOrderIds []string      `json:"removed_order_ids"`
+	OnHoldOrderIds  []string      `json:"on_hold_order_ids"`
+	Failed          []BatchResult `json:"failed"`
+}
+
+// runBounded calls f for 0..n-1 with at most limit calls at a time and
+// returns when all of them have returned.
+func runBounded(n int, limit int, f func(i int)) {
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func newBatchResult(index int, orderId string, resp proto.Message, err error) BatchResult {
+	result := BatchResult{Index: index, OrderId: orderId}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	s, err := m.MarshalToString(resp)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Response = json.RawMessage(s)
+	return result
+}
+
+// Batch places and removes orders concurrently. Removals run first so that
+// replacing quotes doesn't briefly double the exposure.
+func (t *Service) Batch(params BatchParams) BatchResponse {
+	result := BatchResponse{
+		Place:  make([]BatchResult, len(params.Place)),
+		Remove: make([]BatchResult, len(params.Remove)),
+	}
+
+	runBounded(len(params.Remove), batchParallelism, func(i int) {
+		orderId := params.Remove[i]
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.RemoveOrder(ctx, orderId, 0)
+		result.Remove[i] = newBatchResult(i, orderId, resp, err)
+	})
+
+	runBounded(len(params.Place), batchParallelism, func(i int) {
+		p := params.Place[i]
+		if err := p.Validate(); err != nil {
+			result.Place[i] = newBatchResult(i, p.OrderId, nil, err)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.PlaceOrder(ctx, p.PairId, p.Side, p.Price, p.Quantity, p.OrderId, p.ReplaceOrderId, p.ImmediateOrCancel)
+		result.Place[i] = newBatchResult(i, p.OrderId, resp, err)
+	})
+
+	return result
+}
+
+// RemovePairOrders removes all own orders of pairId.
+func (t *Service) RemovePairOrders(pairId string) (*RemovePairOrdersResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+	defer cancel()
+	resp, err := t.ListOrders(ctx, pairId, pb.ListOrdersRequest_OWN, 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	if orders, ok := resp.Orders[pairId]; ok {
+		for _, o := range append(orders.BuyOrders, orders.SellOrders...) {
+			ids = append(ids, o.LocalId)
+		}
+	}
+
+	result := &RemovePairOrdersResponse{
+		RemovedOrderIds: []string{},
+		OnHoldOrderIds:  []string{},
+		Failed:          []BatchResult{},
+	}
+	var mutex sync.Mutex
+	runBounded(len(ids), batchParallelism, func(i int) {
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.RemoveOrder(ctx, ids[i], 0)
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err != nil {
+			result.Failed = append(result.Failed, BatchResult{Index: i, OrderId: ids[i], Error: err.Error()})
+		} else if resp.QuantityOnHold > 0 {
+			result.OnHoldOrderIds = append(result.OnHoldOrderIds, ids[i])
+		} else {
+			result.RemovedOrderIds = append(result.RemovedOrderIds, ids[i])
+		}
+	})
+	return result, nil
+}
+
+func (t *Service) configureOrdersRouter(r *gin.RouterGroup) {
+	r.POST("/v1/xud/removeallorders", auth.Require(auth.ScopeTrade), func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.RemoveAllOrders(ctx)
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	r.POST("/v1/xud/removeallorders/:base/:quote", auth.Require(auth.ScopeTrade), func(c *gin.Context) {
+		pairId := c.Param("base") + "/" + c.Param("quote")
+		resp, err := t.RemovePairOrders(pairId)
+		if err != nil {
+			utils.JsonError(c, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	})
+
+	r.POST("/v1/xud/batch", auth.Require(auth.ScopeTrade), func(c *gin.Context) {
+		var params BatchParams
+		if err := c.ShouldBindJSON(&params); err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if n := len(params.Place) + len(params.Remove); n == 0 || n > maxBatchSize {
+			utils.JsonError(c, fmt.Sprintf("a batch must have 1 to %d items", maxBatchSize), http.StatusBadRequest)
+			return
+		}
+		c.JSON(http.StatusOK, t.Batch(params))
+	})
+}
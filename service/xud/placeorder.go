@@ -0,0 +1,196 @@
+package xud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	pb "github.com/ExchangeUnion/xud-docker-api/service/xud/xudrpc"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/gorilla/websocket"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	placeOrderWriteTimeout = 10 * time.Second
+	// how long a WebSocket client has to send the order after connecting
+	placeOrderReadTimeout = 30 * time.Second
+)
+
+var (
+	placeOrderUpgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+)
+
+// PlaceOrderProgress is pushed to the client for every PlaceOrderEvent. Type
+// is "match", "swapSuccess", "swapFailure" or "remainingOrder" with the
+// event in Data, then "done" when xud has finished processing the order or
+// "error" with Message if placing it failed.
+type PlaceOrderProgress struct {
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+func (p PlaceOrderParams) Validate() error {
+	if p.PairId == "" {
+		return errors.New("pairId is required")
+	}
+	if p.Quantity == 0 {
+		return errors.New("quantity must be greater than 0")
+	}
+	return nil
+}
+
+func newPlaceOrderProgress(event *pb.PlaceOrderEvent) (PlaceOrderProgress, error) {
+	var kind string
+	var msg proto.Message
+	switch e := event.Event.(type) {
+	case *pb.PlaceOrderEvent_Match:
+		kind, msg = "match", e.Match
+	case *pb.PlaceOrderEvent_SwapSuccess:
+		kind, msg = "swapSuccess", e.SwapSuccess
+	case *pb.PlaceOrderEvent_SwapFailure:
+		kind, msg = "swapFailure", e.SwapFailure
+	case *pb.PlaceOrderEvent_RemainingOrder:
+		kind, msg = "remainingOrder", e.RemainingOrder
+	default:
+		return PlaceOrderProgress{}, errors.New("unknown place order event")
+	}
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	s, err := m.MarshalToString(msg)
+	if err != nil {
+		return PlaceOrderProgress{}, err
+	}
+	return PlaceOrderProgress{Type: kind, Data: json.RawMessage(s)}, nil
+}
+
+// placeOrder places the order and calls send for every event until xud is
+// done or the call fails. The call doesn't use ctx, so that a client going
+// away doesn't cancel the order in the middle of a swap. Cancelling ctx or a
+// failed send only stops the reporting, the rest of the stream is drained.
+func (t *Service) placeOrder(ctx context.Context, params PlaceOrderParams, send func(PlaceOrderProgress) bool) {
+	stream, err := t.PlaceOrderStream(context.Background(), params.PairId, params.Side, params.Price, params.Quantity, params.OrderId, params.ReplaceOrderId, params.ImmediateOrCancel)
+	if err != nil {
+		send(PlaceOrderProgress{Type: "error", Message: err.Error()})
+		return
+	}
+	sending := true
+	for {
+		event, err := stream.Recv()
+		if sending && ctx.Err() != nil {
+			sending = false
+		}
+		if err == io.EOF {
+			if sending {
+				send(PlaceOrderProgress{Type: "done"})
+			}
+			return
+		}
+		if err != nil {
+			if sending {
+				send(PlaceOrderProgress{Type: "error", Message: err.Error()})
+			}
+			return
+		}
+		if !sending {
+			continue
+		}
+		progress, err := newPlaceOrderProgress(event)
+		if err != nil {
+			t.GetLogger().Warnf("Failed to convert place order event: %s", err)
+			continue
+		}
+		if !send(progress) {
+			sending = false
+		}
+	}
+}
+
+// placeOrderSSE takes the order as the JSON body and responds with a
+// Server-Sent Event per PlaceOrderProgress, named after its type.
+func (t *Service) placeOrderSSE(c *gin.Context) {
+	var params PlaceOrderParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		utils.JsonError(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := params.Validate(); err != nil {
+		utils.JsonError(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	t.placeOrder(c.Request.Context(), params, func(progress PlaceOrderProgress) bool {
+		switch progress.Type {
+		case "done":
+			c.SSEvent(progress.Type, "{}")
+		case "error":
+			c.SSEvent(progress.Type, gin.H{"message": progress.Message})
+		default:
+			c.SSEvent(progress.Type, progress.Data)
+		}
+		c.Writer.Flush()
+		return c.Request.Context().Err() == nil
+	})
+}
+
+// placeOrderWebSocket reads the order as the first message and then writes
+// every PlaceOrderProgress as a JSON message. The connection is closed after
+// "done" or "error".
+func (t *Service) placeOrderWebSocket(c *gin.Context) {
+	conn, err := placeOrderUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		t.GetLogger().Errorf("Failed to upgrade to websocket: %s", err)
+		return
+	}
+	defer conn.Close()
+
+	send := func(progress PlaceOrderProgress) bool {
+		j, _ := json.Marshal(progress)
+		_ = conn.SetWriteDeadline(time.Now().Add(placeOrderWriteTimeout))
+		return conn.WriteMessage(websocket.TextMessage, j) == nil
+	}
+
+	var params PlaceOrderParams
+	_ = conn.SetReadDeadline(time.Now().Add(placeOrderReadTimeout))
+	if err := conn.ReadJSON(&params); err != nil {
+		send(PlaceOrderProgress{Type: "error", Message: "invalid order: " + err.Error()})
+		return
+	}
+	if err := params.Validate(); err != nil {
+		send(PlaceOrderProgress{Type: "error", Message: err.Error()})
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the client doesn't send anything else, reading only detects the close
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	t.placeOrder(ctx, params, send)
+}
+
+func (t *Service) configurePlaceOrderRouter(r *gin.RouterGroup) {
+	r.POST("/v1/xud/placeorder/stream", auth.Require(auth.ScopeTrade), t.placeOrderSSE)
+	r.GET("/v1/xud/placeorder/stream", auth.Require(auth.ScopeTrade), t.placeOrderWebSocket)
+}
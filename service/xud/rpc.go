@@ -11,6 +11,12 @@ import (
 	pb "github.com/ExchangeUnion/xud-docker-api/service/xud/xudrpc"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"time"
+)
+
+const (
+	streamRetryInterval = 5 * time.Second
 )
 
 var (
@@ -30,14 +36,10 @@ type Clients struct {
 	InitClient pb.XudInitClient
 }
 
-func NewRpcClient(config config.RpcConfig, service *core.SingleContainerService) *RpcClient {
-	host := config["host"].(string)
-	port := uint16(config["port"].(float64))
-	tlsCert := config["tlsCert"].(string)
-
+func NewRpcClient(config config.GrpcConfig, service *core.SingleContainerService) *RpcClient {
 	logger := service.GetLogger().WithField("name", fmt.Sprintf("service.%s.rpc", service.GetName()))
 
-	conn := rpc.NewGrpcConn(host, port, tlsCert, "", logger, func(conn *grpc.ClientConn) interface{} {
+	conn := rpc.NewGrpcConn(config.Host, config.Port, config.TlsCert, "", logger, func(conn *grpc.ClientConn) interface{} {
 		return Clients{
 			Client:     pb.NewXudClient(conn),
 			InitClient: pb.NewXudInitClient(conn),
@@ -60,6 +62,41 @@ func (t *RpcClient) Close() error {
 	return nil
 }
 
+func (t *RpcClient) GetGrpcStates() map[string]connectivity.State {
+	return map[string]connectivity.State{"default": t.conn.GetState()}
+}
+
+// Reconfigure applies a changed rpc block of config.json. The connection is
+// only reopened if the endpoint has changed.
+func (t *RpcClient) Reconfigure(c config.ServiceConfig) error {
+	rpcConfig, err := c.GrpcConfig()
+	if err != nil {
+		return err
+	}
+	t.conn.Update(rpcConfig.Host, rpcConfig.Port, rpcConfig.TlsCert, "")
+	return nil
+}
+
+// Follow runs follow, which is expected to consume a server stream, until
+// ctx is done. Whenever follow returns it is run again as soon as the gRPC
+// connection is reopened, or after streamRetryInterval.
+func (t *RpcClient) Follow(ctx context.Context, name string, follow func(ctx context.Context) error) {
+	for {
+		opened := t.conn.Opened()
+		err := follow(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		t.logger.Debugf("%s stream ended: %s", name, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-opened:
+		case <-time.After(streamRetryInterval):
+		}
+	}
+}
+
 func (t *RpcClient) getClient() (xudrpc.XudClient, error) {
 	clients := t.conn.GetClient()
 	if clients == nil {
@@ -229,6 +266,91 @@ func (t *RpcClient) PlaceOrder(ctx context.Context, pairId string, side pb.Order
 	return client.PlaceOrderSync(ctx, &req)
 }
 
+// PlaceOrderStream places an order with the streaming PlaceOrder call, which
+// reports every match, swap and the remaining order as they happen.
+func (t *RpcClient) PlaceOrderStream(ctx context.Context, pairId string, side pb.OrderSide, price float64, quantity uint64, orderId string, replaceOrderId string, immediateOrCancel bool) (pb.Xud_PlaceOrderClient, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.PlaceOrderRequest{
+		PairId:            pairId,
+		Side:              side,
+		Price:             price,
+		Quantity:          quantity,
+		OrderId:           orderId,
+		ReplaceOrderId:    replaceOrderId,
+		ImmediateOrCancel: immediateOrCancel,
+	}
+	return client.PlaceOrder(ctx, &req)
+}
+
+func (t *RpcClient) WalletDeposit(ctx context.Context, currency string) (*pb.DepositResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.DepositRequest{
+		Currency: currency,
+	}
+	return client.WalletDeposit(ctx, &req)
+}
+
+func (t *RpcClient) WalletWithdraw(ctx context.Context, currency string, destination string, amount uint64, all bool, fee uint32) (*pb.WithdrawResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.WithdrawRequest{
+		Currency:    currency,
+		Destination: destination,
+		Amount:      amount,
+		All:         all,
+		Fee:         fee,
+	}
+	return client.WalletWithdraw(ctx, &req)
+}
+
+func (t *RpcClient) OpenChannel(ctx context.Context, nodeIdentifier string, currency string, amount uint64, pushAmount uint64, fee uint64) (*pb.OpenChannelResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.OpenChannelRequest{
+		NodeIdentifier: nodeIdentifier,
+		Currency:       currency,
+		Amount:         amount,
+		PushAmount:     pushAmount,
+		Fee:            fee,
+	}
+	return client.OpenChannel(ctx, &req)
+}
+
+func (t *RpcClient) CloseChannel(ctx context.Context, nodeIdentifier string, currency string, force bool, destination string, amount uint64, fee uint64) (*pb.CloseChannelResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.CloseChannelRequest{
+		NodeIdentifier: nodeIdentifier,
+		Currency:       currency,
+		Force:          force,
+		Destination:    destination,
+		Amount:         amount,
+		Fee:            fee,
+	}
+	return client.CloseChannel(ctx, &req)
+}
+
+func (t *RpcClient) RemoveAllOrders(ctx context.Context) (*pb.RemoveAllOrdersResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.RemoveAllOrdersRequest{}
+	return client.RemoveAllOrders(ctx, &req)
+}
+
 func (t *RpcClient) RemoveOrder(ctx context.Context, orderId string, quantity uint64) (*pb.RemoveOrderResponse, error) {
 	client, err := t.getClient()
 	if err != nil {
@@ -240,3 +362,167 @@ func (t *RpcClient) RemoveOrder(ctx context.Context, orderId string, quantity ui
 	}
 	return client.RemoveOrder(ctx, &req)
 }
+
+func (t *RpcClient) ListPeers(ctx context.Context) (*pb.ListPeersResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.ListPeersRequest{}
+	return client.ListPeers(ctx, &req)
+}
+
+func (t *RpcClient) Connect(ctx context.Context, nodeUri string) (*pb.ConnectResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.ConnectRequest{
+		NodeUri: nodeUri,
+	}
+	return client.Connect(ctx, &req)
+}
+
+func (t *RpcClient) Ban(ctx context.Context, nodeIdentifier string) (*pb.BanResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.BanRequest{
+		NodeIdentifier: nodeIdentifier,
+	}
+	return client.Ban(ctx, &req)
+}
+
+func (t *RpcClient) Unban(ctx context.Context, nodeIdentifier string, reconnect bool) (*pb.UnbanResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.UnbanRequest{
+		NodeIdentifier: nodeIdentifier,
+		Reconnect:      reconnect,
+	}
+	return client.Unban(ctx, &req)
+}
+
+func (t *RpcClient) DiscoverNodes(ctx context.Context, nodeIdentifier string) (*pb.DiscoverNodesResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.DiscoverNodesRequest{
+		NodeIdentifier: nodeIdentifier,
+	}
+	return client.DiscoverNodes(ctx, &req)
+}
+
+func (t *RpcClient) GetNodeInfo(ctx context.Context, nodeIdentifier string) (*pb.GetNodeInfoResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.GetNodeInfoRequest{
+		NodeIdentifier: nodeIdentifier,
+	}
+	return client.GetNodeInfo(ctx, &req)
+}
+
+func (t *RpcClient) ListCurrencies(ctx context.Context) (*pb.ListCurrenciesResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.ListCurrenciesRequest{}
+	return client.ListCurrencies(ctx, &req)
+}
+
+func (t *RpcClient) AddCurrency(ctx context.Context, currency string, swapClient pb.Currency_SwapClient, tokenAddress string, decimalPlaces uint32) (*pb.AddCurrencyResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.Currency{
+		Currency:      currency,
+		SwapClient:    swapClient,
+		TokenAddress:  tokenAddress,
+		DecimalPlaces: decimalPlaces,
+	}
+	return client.AddCurrency(ctx, &req)
+}
+
+func (t *RpcClient) RemoveCurrency(ctx context.Context, currency string) (*pb.RemoveCurrencyResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.RemoveCurrencyRequest{
+		Currency: currency,
+	}
+	return client.RemoveCurrency(ctx, &req)
+}
+
+func (t *RpcClient) AddPair(ctx context.Context, baseCurrency string, quoteCurrency string) (*pb.AddPairResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.AddPairRequest{
+		BaseCurrency:  baseCurrency,
+		QuoteCurrency: quoteCurrency,
+	}
+	return client.AddPair(ctx, &req)
+}
+
+func (t *RpcClient) RemovePair(ctx context.Context, pairId string) (*pb.RemovePairResponse, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.RemovePairRequest{
+		PairId: pairId,
+	}
+	return client.RemovePair(ctx, &req)
+}
+
+func (t *RpcClient) SubscribeOrders(ctx context.Context, existing bool) (pb.Xud_SubscribeOrdersClient, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.SubscribeOrdersRequest{
+		Existing: existing,
+	}
+	return client.SubscribeOrders(ctx, &req)
+}
+
+func (t *RpcClient) SubscribeSwaps(ctx context.Context, includeTaker bool) (pb.Xud_SubscribeSwapsClient, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.SubscribeSwapsRequest{
+		IncludeTaker: includeTaker,
+	}
+	return client.SubscribeSwaps(ctx, &req)
+}
+
+func (t *RpcClient) SubscribeSwapsAccepted(ctx context.Context) (pb.Xud_SubscribeSwapsAcceptedClient, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.SubscribeSwapsAcceptedRequest{}
+	return client.SubscribeSwapsAccepted(ctx, &req)
+}
+
+func (t *RpcClient) SubscribeSwapFailures(ctx context.Context, includeTaker bool) (pb.Xud_SubscribeSwapFailuresClient, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	req := pb.SubscribeSwapsRequest{
+		IncludeTaker: includeTaker,
+	}
+	return client.SubscribeSwapFailures(ctx, &req)
+}
@@ -0,0 +1,219 @@
+package xud
+
+import (
+	"context"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SwapAccepted = "accepted"
+	SwapSuccess  = "success"
+	SwapFailure  = "failure"
+
+	swapBufferSize = 100
+	// accepted swaps which neither succeed nor fail within this time are
+	// forgotten
+	pendingSwapTtl = time.Hour
+)
+
+// SwapEvent is a swap being accepted (as maker), succeeding or failing.
+// Amounts are in satoshis. Duration is the time in milliseconds since the
+// swap was accepted, if the proxy saw that.
+type SwapEvent struct {
+	Timestamp        int64   `json:"timestamp"`
+	Type             string  `json:"type"`
+	Role             string  `json:"role,omitempty"`
+	OrderId          string  `json:"orderId"`
+	LocalId          string  `json:"localId,omitempty"`
+	PairId           string  `json:"pairId"`
+	Quantity         uint64  `json:"quantity"`
+	Price            float64 `json:"price,omitempty"`
+	PeerPubKey       string  `json:"peerPubKey"`
+	RHash            string  `json:"rHash,omitempty"`
+	AmountSent       uint64  `json:"amountSent,omitempty"`
+	AmountReceived   uint64  `json:"amountReceived,omitempty"`
+	CurrencySent     string  `json:"currencySent,omitempty"`
+	CurrencyReceived string  `json:"currencyReceived,omitempty"`
+	FailureReason    string  `json:"failureReason,omitempty"`
+	Duration         int64   `json:"duration,omitempty"`
+}
+
+// pendingSwap is a swap which was accepted and has not finished yet.
+type pendingSwap struct {
+	acceptedAt time.Time
+	role       string
+}
+
+// SwapWatcher follows the swap streams of xud and emits their events.
+type SwapWatcher struct {
+	client      *RpcClient
+	broadcaster *utils.Broadcaster
+	// pending maps the r_hash and the order and peer of accepted swaps to
+	// the swap
+	pending map[string]pendingSwap
+	mutex   *sync.Mutex
+	logger  *logrus.Entry
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func NewSwapWatcher(client *RpcClient, logger *logrus.Entry) *SwapWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SwapWatcher{
+		client:      client,
+		broadcaster: utils.NewBroadcaster(),
+		pending:     map[string]pendingSwap{},
+		mutex:       &sync.Mutex{},
+		logger:      logger,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+func (t *SwapWatcher) Run() {
+	go t.client.Follow(t.ctx, "SubscribeSwapsAccepted", t.followAccepted)
+	go t.client.Follow(t.ctx, "SubscribeSwapFailures", t.followFailures)
+	t.client.Follow(t.ctx, "SubscribeSwaps", t.followSwaps)
+}
+
+func (t *SwapWatcher) followAccepted(ctx context.Context) error {
+	stream, err := t.client.SubscribeSwapsAccepted(ctx)
+	if err != nil {
+		return err
+	}
+	for {
+		s, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		// only makers are told about accepted swaps
+		pending := pendingSwap{acceptedAt: now, role: "maker"}
+		t.mutex.Lock()
+		t.pending[s.RHash] = pending
+		t.pending[s.OrderId+"@"+s.PeerPubKey] = pending
+		t.mutex.Unlock()
+		t.emit(SwapEvent{
+			Timestamp:        now.UnixNano() / int64(time.Millisecond),
+			Type:             SwapAccepted,
+			Role:             pending.role,
+			OrderId:          s.OrderId,
+			LocalId:          s.LocalId,
+			PairId:           s.PairId,
+			Quantity:         s.Quantity,
+			Price:            s.Price,
+			PeerPubKey:       s.PeerPubKey,
+			RHash:            s.RHash,
+			AmountSent:       s.AmountSending,
+			AmountReceived:   s.AmountReceiving,
+			CurrencySent:     s.CurrencySending,
+			CurrencyReceived: s.CurrencyReceiving,
+		})
+	}
+}
+
+func (t *SwapWatcher) followSwaps(ctx context.Context) error {
+	stream, err := t.client.SubscribeSwaps(ctx, true)
+	if err != nil {
+		return err
+	}
+	for {
+		s, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		duration, _ := t.finish(now, s.RHash, s.OrderId+"@"+s.PeerPubKey)
+		t.emit(SwapEvent{
+			Timestamp:        now.UnixNano() / int64(time.Millisecond),
+			Type:             SwapSuccess,
+			Role:             strings.ToLower(s.Role.String()),
+			OrderId:          s.OrderId,
+			LocalId:          s.LocalId,
+			PairId:           s.PairId,
+			Quantity:         s.Quantity,
+			Price:            s.Price,
+			PeerPubKey:       s.PeerPubKey,
+			RHash:            s.RHash,
+			AmountSent:       s.AmountSent,
+			AmountReceived:   s.AmountReceived,
+			CurrencySent:     s.CurrencySent,
+			CurrencyReceived: s.CurrencyReceived,
+			Duration:         duration,
+		})
+	}
+}
+
+func (t *SwapWatcher) followFailures(ctx context.Context) error {
+	stream, err := t.client.SubscribeSwapFailures(ctx, true)
+	if err != nil {
+		return err
+	}
+	for {
+		s, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		// failures don't carry the role, it is only known for accepted swaps
+		duration, role := t.finish(now, s.OrderId+"@"+s.PeerPubKey)
+		t.emit(SwapEvent{
+			Timestamp:     now.UnixNano() / int64(time.Millisecond),
+			Type:          SwapFailure,
+			Role:          role,
+			OrderId:       s.OrderId,
+			PairId:        s.PairId,
+			Quantity:      s.Quantity,
+			PeerPubKey:    s.PeerPubKey,
+			FailureReason: s.FailureReason,
+			Duration:      duration,
+		})
+	}
+}
+
+// finish forgets the pending swap under keys and returns the milliseconds
+// since it was accepted and its role, or 0 and "" if it is unknown.
+func (t *SwapWatcher) finish(now time.Time, keys ...string) (int64, string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var duration int64
+	var swap pendingSwap
+	for _, key := range keys {
+		if p, ok := t.pending[key]; ok {
+			swap = p
+		}
+	}
+	if !swap.acceptedAt.IsZero() {
+		duration = int64(now.Sub(swap.acceptedAt) / time.Millisecond)
+	}
+
+	for key, p := range t.pending {
+		if p == swap || now.Sub(p.acceptedAt) > pendingSwapTtl {
+			delete(t.pending, key)
+		}
+	}
+	return duration, swap.role
+}
+
+func (t *SwapWatcher) emit(event SwapEvent) {
+	if t.broadcaster.Emit(event) > 0 {
+		t.logger.Warn("Dropped swap event for a slow subscriber")
+	}
+}
+
+func (t *SwapWatcher) Subscribe() (<-chan SwapEvent, func()) {
+	ch := make(chan SwapEvent, swapBufferSize)
+	return ch, t.broadcaster.Add(ch)
+}
+
+// Close stops following the streams and closes the channels of all
+// subscribers.
+func (t *SwapWatcher) Close() {
+	t.cancel()
+	t.broadcaster.Close()
+}
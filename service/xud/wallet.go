@@ -0,0 +1,146 @@
+package xud
+
+import (
+	"context"
+	"errors"
+	"github.com/ExchangeUnion/xud-docker-api/auth"
+	"github.com/ExchangeUnion/xud-docker-api/config"
+	"github.com/ExchangeUnion/xud-docker-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const withdrawalConfirmationTtl = 2 * time.Minute
+
+type WithdrawParams struct {
+	Currency    string `json:"currency" binding:"required"`
+	Destination string `json:"destination" binding:"required"`
+	// Amount is in satoshis and ignored if All is set
+	Amount uint64 `json:"amount"`
+	All    bool   `json:"all"`
+	// Fee is in satoshis per byte, xud estimates it if 0
+	Fee               uint32 `json:"fee"`
+	ConfirmationToken string `json:"confirmationToken"`
+}
+
+// WithdrawalConfirmation is returned for a withdrawal without a confirmation
+// token. Sending the same withdrawal again with ConfirmationToken executes it.
+type WithdrawalConfirmation struct {
+	ConfirmationToken string         `json:"confirmationToken"`
+	ExpiresAt         int64          `json:"expiresAt"`
+	Withdrawal        WithdrawParams `json:"withdrawal"`
+}
+
+func (p WithdrawParams) Validate(network string) error {
+	if !p.All && p.Amount == 0 {
+		return errors.New("either amount or all is required")
+	}
+	if p.All && p.Amount != 0 {
+		return errors.New("amount and all are mutually exclusive")
+	}
+	return ValidateAddress(network, p.Currency, p.Destination)
+}
+
+// same reports whether p is the withdrawal which other confirms.
+func (p WithdrawParams) same(other WithdrawParams) bool {
+	p.ConfirmationToken = ""
+	other.ConfirmationToken = ""
+	return p == other
+}
+
+// Withdrawals holds the withdrawals waiting for their confirmation. A token
+// is valid once, for the parameters it was issued for, and expires after
+// withdrawalConfirmationTtl.
+type Withdrawals struct {
+	pending map[string]WithdrawalConfirmation
+	mutex   *sync.Mutex
+}
+
+func NewWithdrawals() *Withdrawals {
+	return &Withdrawals{
+		pending: map[string]WithdrawalConfirmation{},
+		mutex:   &sync.Mutex{},
+	}
+}
+
+func (t *Withdrawals) Request(params WithdrawParams) WithdrawalConfirmation {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	for token, item := range t.pending {
+		if now.Unix() > item.ExpiresAt {
+			delete(t.pending, token)
+		}
+	}
+
+	params.ConfirmationToken = ""
+	result := WithdrawalConfirmation{
+		ConfirmationToken: uuid.New().String(),
+		ExpiresAt:         now.Add(withdrawalConfirmationTtl).Unix(),
+		Withdrawal:        params,
+	}
+	t.pending[result.ConfirmationToken] = result
+	return result
+}
+
+func (t *Withdrawals) Confirm(params WithdrawParams) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	item, ok := t.pending[params.ConfirmationToken]
+	if !ok || time.Now().Unix() > item.ExpiresAt {
+		delete(t.pending, params.ConfirmationToken)
+		return errors.New("invalid or expired confirmation token")
+	}
+	if !item.Withdrawal.same(params) {
+		return errors.New("the withdrawal does not match the confirmation token")
+	}
+	delete(t.pending, params.ConfirmationToken)
+	return nil
+}
+
+func (t *Service) configureWalletRouter(r *gin.RouterGroup) {
+	r.GET("/v1/xud/walletdeposit/:currency", auth.Require(auth.ScopeWallet), func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.WalletDeposit(ctx, strings.ToUpper(c.Param("currency")))
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+
+	r.POST("/v1/xud/walletwithdraw", auth.Require(auth.ScopeWallet), func(c *gin.Context) {
+		var params WithdrawParams
+		if err := c.ShouldBindJSON(&params); err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		params.Currency = strings.ToUpper(params.Currency)
+		if err := params.Validate(t.network); err != nil {
+			utils.JsonError(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if params.ConfirmationToken == "" {
+			c.JSON(http.StatusAccepted, t.withdrawals.Request(params))
+			return
+		}
+		if err := t.withdrawals.Confirm(params); err != nil {
+			utils.JsonError(c, err.Error(), http.StatusConflict)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultApiTimeout)
+		defer cancel()
+		resp, err := t.WalletWithdraw(ctx, params.Currency, params.Destination, params.Amount, params.All, params.Fee)
+		if err != nil {
+			t.GetLogger().Errorf("Failed to withdraw %s to %s: %s", params.Currency, params.Destination, err)
+		} else {
+			t.GetLogger().Infof("Withdrew %s to %s in transaction %s", params.Currency, params.Destination, resp.TransactionId)
+		}
+		utils.HandleProtobufResponse(c, resp, err)
+	})
+}
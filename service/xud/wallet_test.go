@@ -0,0 +1,129 @@
+package xud
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithdrawParamsValidate(t *testing.T) {
+	address := "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0"
+	tests := []struct {
+		name   string
+		params WithdrawParams
+		err    string
+	}{
+		{name: "amount", params: WithdrawParams{Currency: "BTC", Destination: address, Amount: 1000}},
+		{name: "all", params: WithdrawParams{Currency: "BTC", Destination: address, All: true}},
+		{name: "neither", params: WithdrawParams{Currency: "BTC", Destination: address}, err: "either amount or all is required"},
+		{name: "both", params: WithdrawParams{Currency: "BTC", Destination: address, Amount: 1000, All: true}, err: "mutually exclusive"},
+		{name: "address", params: WithdrawParams{Currency: "LTC", Destination: address, Amount: 1000}, err: "invalid mainnet LTC address"},
+	}
+	for _, test := range tests {
+		err := test.params.Validate("mainnet")
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestWithdrawalConfirmation(t *testing.T) {
+	params := WithdrawParams{Currency: "BTC", Destination: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Amount: 1000}
+
+	tests := []struct {
+		name string
+		// confirm changes the requested withdrawal before confirming it
+		confirm func(p *WithdrawParams)
+		err     string
+	}{
+		{name: "same", confirm: func(p *WithdrawParams) {}},
+		{name: "amount", confirm: func(p *WithdrawParams) { p.Amount = 2000 }, err: "does not match"},
+		{name: "destination", confirm: func(p *WithdrawParams) { p.Destination = "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy" }, err: "does not match"},
+		{name: "fee", confirm: func(p *WithdrawParams) { p.Fee = 10 }, err: "does not match"},
+		{name: "unknown token", confirm: func(p *WithdrawParams) { p.ConfirmationToken = "foo" }, err: "invalid or expired"},
+		{name: "no token", confirm: func(p *WithdrawParams) { p.ConfirmationToken = "" }, err: "invalid or expired"},
+	}
+	for _, test := range tests {
+		w := NewWithdrawals()
+		c := w.Request(params)
+		if c.ConfirmationToken == "" {
+			t.Fatalf("%s: empty confirmation token", test.name)
+		}
+		if c.Withdrawal != params {
+			t.Errorf("%s: got withdrawal %+v, want %+v", test.name, c.Withdrawal, params)
+		}
+
+		p := params
+		p.ConfirmationToken = c.ConfirmationToken
+		test.confirm(&p)
+		err := w.Confirm(p)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestWithdrawalConfirmationOnce(t *testing.T) {
+	w := NewWithdrawals()
+	params := WithdrawParams{Currency: "BTC", Destination: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", All: true}
+
+	// a mismatch doesn't use up the token
+	p := params
+	p.ConfirmationToken = w.Request(params).ConfirmationToken
+	p.All = false
+	p.Amount = 1
+	if err := w.Confirm(p); err == nil {
+		t.Fatal("confirmed a different withdrawal")
+	}
+	p.All = true
+	p.Amount = 0
+	if err := w.Confirm(p); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := w.Confirm(p); err == nil {
+		t.Fatal("confirmed a token twice")
+	}
+}
+
+func TestWithdrawalConfirmationExpiry(t *testing.T) {
+	w := NewWithdrawals()
+	params := WithdrawParams{Currency: "BTC", Destination: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Amount: 1000}
+	c := w.Request(params)
+
+	// expire the token
+	w.mutex.Lock()
+	item := w.pending[c.ConfirmationToken]
+	item.ExpiresAt = time.Now().Add(-time.Second).Unix()
+	w.pending[c.ConfirmationToken] = item
+	w.mutex.Unlock()
+
+	p := params
+	p.ConfirmationToken = c.ConfirmationToken
+	if err := w.Confirm(p); err == nil || !strings.Contains(err.Error(), "invalid or expired") {
+		t.Fatalf("got error %v for an expired token", err)
+	}
+
+	// expired tokens are removed when the next one is requested
+	c = w.Request(params)
+	w.mutex.Lock()
+	item = w.pending[c.ConfirmationToken]
+	item.ExpiresAt = time.Now().Add(-time.Second).Unix()
+	w.pending[c.ConfirmationToken] = item
+	w.mutex.Unlock()
+	w.Request(params)
+	if n := len(w.pending); n != 1 {
+		t.Errorf("%d pending withdrawals, want 1", n)
+	}
+}
@@ -13,31 +13,69 @@ import (
 type Service struct {
 	*core.SingleContainerService
 	*RpcClient
+
+	network           string
+	orderBook         *OrderBook
+	swapWatcher       *SwapWatcher
+	withdrawals       *Withdrawals
+	passwordListeners []func(password string)
+}
+
+func init() {
+	core.Register(core.Factory{
+		Name:         "xud",
+		DisplayName:  "XUD",
+		Rpc:          config.Grpc,
+		Dependencies: []string{"lndbtc", "lndltc", "connext"},
+		Commands: []core.ConsoleCommand{
+			{Name: "xucli", Description: "xud cli", Command: "xucli"},
+		},
+		ParseLog: ParseLog,
+		New: func(ctx core.FactoryContext, c config.ServiceConfig) (core.Service, error) {
+			rpc, err := c.GrpcConfig()
+			if err != nil {
+				return nil, err
+			}
+			return New(c.Name, ctx.Network, ctx.Services, ctx.ContainerName, ctx.DockerClient, *rpc), nil
+		},
+	})
 }
 
 func New(
 	name string,
+	network string,
 	services map[string]core.Service,
 	containerName string,
 	dockerClient *docker.Client,
-	rpcConfig config.RpcConfig,
+	rpcConfig config.GrpcConfig,
 ) *Service {
 	base := core.NewSingleContainerService(name, services, containerName, dockerClient)
+	rpcClient := NewRpcClient(rpcConfig, base)
+	orderBook := NewOrderBook(rpcClient, base.GetLogger().WithField("name", fmt.Sprintf("service.%s.orderbook", name)))
+
+	swapWatcher := NewSwapWatcher(rpcClient, base.GetLogger().WithField("name", fmt.Sprintf("service.%s.swaps", name)))
+
+	go orderBook.Run()
+	go swapWatcher.Run()
 
 	return &Service{
 		SingleContainerService: base,
-		RpcClient:              NewRpcClient(rpcConfig, base),
+		RpcClient:              rpcClient,
+		network:                network,
+		orderBook:              orderBook,
+		swapWatcher:            swapWatcher,
+		withdrawals:            NewWithdrawals(),
 	}
 }
 
-func (t *Service) GetStatus(ctx context.Context) string {
+func (t *Service) GetStatus(ctx context.Context) core.Status {
 	status := t.SingleContainerService.GetStatus(ctx)
-	if status == "Disabled" {
+	if status.State == core.StateDisabled {
 		return status
 	}
-	if status != "Container running" {
+	if !status.IsReady() {
 		if ctx.Value("LauncherState") == "setup" {
-			return "Waiting for sync"
+			return core.NewStatus(core.StateWaiting, "Waiting for sync")
 		}
 		return status
 	}
@@ -48,15 +86,15 @@ func (t *Service) GetStatus(ctx context.Context) string {
 	if err != nil {
 		if strings.Contains(err.Error(), "xud is locked") {
 			if _, err := os.Stat("/root/network/data/xud/nodekey.dat"); os.IsNotExist(err) {
-				return "Wallet missing. Create with xucli create/restore."
+				return core.NewStatus(core.StateLocked, "Wallet missing. Create with xucli create/restore.")
 			}
-			return "Wallet locked. Unlock with xucli unlock."
+			return core.NewStatus(core.StateLocked, "Wallet locked. Unlock with xucli unlock.")
 		} else if strings.Contains(err.Error(), "no such file or directory, open '/root/.xud/tls.cert'") {
-			return "Starting..."
+			return core.NewStatus(core.StateStarting, "Starting...")
 		} else if strings.Contains(err.Error(), "xud is starting") {
-			return "Starting..."
+			return core.NewStatus(core.StateStarting, "Starting...")
 		}
-		return fmt.Sprintf("Error: %s", err)
+		return core.ErrorStatus(err)
 	}
 
 	lndbtcStatus := resp.Lnd["BTC"].Status
@@ -64,13 +102,13 @@ func (t *Service) GetStatus(ctx context.Context) string {
 	connextStatus := resp.Connext.Status
 
 	if lndbtcStatus == "Ready" && lndltcStatus == "Ready" && connextStatus == "Ready" {
-		return "Ready"
+		return core.NewStatus(core.StateReady, "Ready")
 	}
 
 	if strings.Contains(lndbtcStatus, "has no active channels") ||
 		strings.Contains(lndltcStatus, "has no active channels") ||
 		strings.Contains(connextStatus, "has no active channels") {
-		return "Waiting for channels"
+		return core.NewStatus(core.StateWaiting, "Waiting for channels")
 	}
 
 	var notReady []string
@@ -84,10 +122,31 @@ func (t *Service) GetStatus(ctx context.Context) string {
 		notReady = append(notReady, "connext")
 	}
 
-	return "Waiting for " + strings.Join(notReady, ", ")
+	return core.NewStatus(core.StateWaiting, "Waiting for "+strings.Join(notReady, ", "))
+}
+
+// SubscribeSwapEvents returns the swap events of xud. The channel is closed
+// when the service is closed.
+func (t *Service) SubscribeSwapEvents() (<-chan SwapEvent, func()) {
+	return t.swapWatcher.Subscribe()
+}
+
+// AddPasswordListener registers a callback which is invoked with the wallet
+// password whenever it is set through the create, restore, unlock or
+// changepass endpoints.
+func (t *Service) AddPasswordListener(listener func(password string)) {
+	t.passwordListeners = append(t.passwordListeners, listener)
+}
+
+func (t *Service) emitPassword(password string) {
+	for _, listener := range t.passwordListeners {
+		listener(password)
+	}
 }
 
 func (t *Service) Close() error {
+	t.orderBook.Close()
+	t.swapWatcher.Close()
 	err := t.RpcClient.Close()
 	if err != nil {
 		t.GetLogger().Errorf("Failed to close RPC client: %s", err)
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Broadcaster sends values to a set of buffered channels without blocking
+// the sender. The channels are typed by the caller, e.g. a chan ReloadEvent,
+// and every value passed to Emit must be of their element type.
+type Broadcaster struct {
+	listeners []reflect.Value
+	mutex     *sync.RWMutex
+}
+
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		listeners: []reflect.Value{},
+		mutex:     &sync.RWMutex{},
+	}
+}
+
+// Add registers the channel ch and returns a function which removes it
+// again. The channel is not closed on removal.
+func (t *Broadcaster) Add(ch interface{}) func() {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Chan {
+		panic("utils: Broadcaster.Add called with a non-channel")
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.listeners = append(t.listeners, v)
+
+	return func() {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		for i, listener := range t.listeners {
+			if listener.Interface() == ch {
+				t.listeners = append(t.listeners[:i], t.listeners[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Emit sends value to every channel which has room for it and returns the
+// number of channels it was dropped for.
+func (t *Broadcaster) Emit(value interface{}) int {
+	v := reflect.ValueOf(value)
+	dropped := 0
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	for _, listener := range t.listeners {
+		if !listener.TrySend(v) {
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// Close closes and removes all channels.
+func (t *Broadcaster) Close() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, listener := range t.listeners {
+		listener.Close()
+	}
+	t.listeners = nil
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestBroadcasterEmit(t *testing.T) {
+	b := NewBroadcaster()
+	full := make(chan int, 1)
+	empty := make(chan int, 2)
+	b.Add(full)
+	b.Add(empty)
+
+	if dropped := b.Emit(1); dropped != 0 {
+		t.Fatalf("Emit(1) dropped %d, want 0", dropped)
+	}
+	if dropped := b.Emit(2); dropped != 1 {
+		t.Fatalf("Emit(2) dropped %d, want 1", dropped)
+	}
+	if v := <-full; v != 1 {
+		t.Errorf("full received %d, want 1", v)
+	}
+	if v := <-empty; v != 1 {
+		t.Errorf("empty received %d, want 1", v)
+	}
+	if v := <-empty; v != 2 {
+		t.Errorf("empty received %d, want 2", v)
+	}
+}
+
+func TestBroadcasterRemove(t *testing.T) {
+	b := NewBroadcaster()
+	a := make(chan string, 1)
+	c := make(chan string, 1)
+	removeA := b.Add(a)
+	b.Add(c)
+
+	removeA()
+	removeA()
+	b.Emit("x")
+
+	if len(a) != 0 {
+		t.Errorf("removed channel received %d values", len(a))
+	}
+	if v := <-c; v != "x" {
+		t.Errorf("received %q, want %q", v, "x")
+	}
+}
+
+func TestBroadcasterClose(t *testing.T) {
+	b := NewBroadcaster()
+	ch := make(chan int, 1)
+	remove := b.Add(ch)
+
+	b.Close()
+	if _, ok := <-ch; ok {
+		t.Fatal("channel is not closed")
+	}
+	// neither panics on the closed channel
+	remove()
+	if dropped := b.Emit(1); dropped != 0 {
+		t.Errorf("Emit after Close dropped %d, want 0", dropped)
+	}
+}